@@ -0,0 +1,172 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	// archiveFileMode is the permission of every file in an archive.
+	archiveFileMode = 0644
+	// archiveLinkMode is the permission of every symbolic link in an archive.
+	archiveLinkMode = 0777
+)
+
+// archiveModTime is the modification time of every file in an archive, so that
+// archives are reproducible. It is the earliest time representable in a zip file.
+var archiveModTime = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// saveWriter returns the function that writes saved files in the given format.
+func saveWriter(format string) (func(string, saveFiles) error, error) {
+	switch format {
+	case "dir":
+		return writeDir, nil
+	case "tar.gz":
+		return writeTarGz, nil
+	case "zip":
+		return writeZip, nil
+	}
+	return nil, fmt.Errorf("unsupported format %q: want one of dir, tar.gz, zip", format)
+}
+
+// writeTarGz writes files into a gzip-compressed tar archive at archivePath.
+// Entries are sorted by path and have a fixed modification time and
+// permissions, so the archive is the same for the same input files.
+func writeTarGz(archivePath string, files saveFiles) error {
+	return writeArchive(archivePath, func(w io.Writer) error {
+		gw := gzip.NewWriter(w)
+		tw := tar.NewWriter(gw)
+		for _, p := range files.sortedPaths() {
+			if link := files[p].link; link != "" {
+				if err := tw.WriteHeader(&tar.Header{
+					Typeflag: tar.TypeSymlink,
+					Name:     p,
+					Linkname: link,
+					Mode:     archiveLinkMode,
+					ModTime:  archiveModTime,
+					Format:   tar.FormatPAX,
+				}); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := copyToArchive(files[p], func(size int64) (io.Writer, error) {
+				return tw, tw.WriteHeader(&tar.Header{
+					Typeflag: tar.TypeReg,
+					Name:     p,
+					Size:     size,
+					Mode:     archiveFileMode,
+					ModTime:  archiveModTime,
+					Format:   tar.FormatPAX,
+				})
+			}); err != nil {
+				return err
+			}
+		}
+		if err := tw.Close(); err != nil {
+			return err
+		}
+		return gw.Close()
+	})
+}
+
+// writeZip writes files into a zip archive at archivePath.
+// Entries are sorted by path and have a fixed modification time and
+// permissions, so the archive is the same for the same input files.
+func writeZip(archivePath string, files saveFiles) error {
+	return writeArchive(archivePath, func(w io.Writer) error {
+		zw := zip.NewWriter(w)
+		for _, p := range files.sortedPaths() {
+			if link := files[p].link; link != "" {
+				// Zip stores symlinks as entries with the symlink mode, whose content is the target.
+				header := &zip.FileHeader{
+					Name:     p,
+					Method:   zip.Store,
+					Modified: archiveModTime,
+				}
+				header.SetMode(os.ModeSymlink | archiveLinkMode)
+				w, err := zw.CreateHeader(header)
+				if err != nil {
+					return err
+				}
+				if _, err := io.WriteString(w, link); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := copyToArchive(files[p], func(int64) (io.Writer, error) {
+				header := &zip.FileHeader{
+					Name:     p,
+					Method:   zip.Deflate,
+					Modified: archiveModTime,
+				}
+				header.SetMode(archiveFileMode)
+				return zw.CreateHeader(header)
+			}); err != nil {
+				return err
+			}
+		}
+		return zw.Close()
+	})
+}
+
+// writeArchive creates the file at archivePath and writes its content with write.
+// The file is removed if writing fails.
+func writeArchive(archivePath string, write func(io.Writer) error) (err error) {
+	f, err := os.OpenFile(archivePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(archivePath)
+		}
+	}()
+	return write(f)
+}
+
+// copyToArchive copies the content of file into the writer returned by create,
+// which is passed the size of the file.
+func copyToArchive(file saveFile, create func(size int64) (io.Writer, error)) error {
+	size := int64(len(file.data))
+	if file.data == nil {
+		info, err := os.Stat(file.src)
+		if err != nil {
+			return err
+		}
+		size = info.Size()
+	}
+	r, err := file.open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	w, err := create(size)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
@@ -0,0 +1,72 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestArchivesAreReproducible(t *testing.T) {
+	for _, format := range []string{"tar.gz", "zip"} {
+		t.Run(format, func(t *testing.T) {
+			write, err := saveWriter(format)
+			if err != nil {
+				t.Fatalf("saveWriter(%q) = (_, %v), want (_, nil)", format, err)
+			}
+			srcDir := t.TempDir()
+			files := saveFiles{}
+			for _, name := range []string{"b/LICENSE", "a/NOTICE", "a/LICENSE"} {
+				src := filepath.Join(srcDir, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(src), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(src, []byte(name), 0600); err != nil {
+					t.Fatal(err)
+				}
+				files.add("example.com/"+name, src)
+			}
+
+			outDir := t.TempDir()
+			var archives [][]byte
+			for i, name := range []string{"first", "second"} {
+				if i > 0 {
+					// Touch the sources, archives must not depend on their mtime.
+					later := time.Now().Add(time.Hour)
+					for _, f := range files {
+						if err := os.Chtimes(f.src, later, later); err != nil {
+							t.Fatal(err)
+						}
+					}
+				}
+				archivePath := filepath.Join(outDir, name+"."+format)
+				if err := write(archivePath, files); err != nil {
+					t.Fatalf("writing %s: %v", archivePath, err)
+				}
+				data, err := os.ReadFile(archivePath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				archives = append(archives, data)
+			}
+			if !bytes.Equal(archives[0], archives[1]) {
+				t.Errorf("%s archives of the same files differ", format)
+			}
+		})
+	}
+}
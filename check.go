@@ -16,15 +16,16 @@ package main
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/google/go-licenses/v2/licenses"
 	"github.com/spf13/cobra"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
+	"k8s.io/klog/v2"
 )
 
 var (
@@ -33,38 +34,71 @@ var (
 		Use:   "check <package> [package...]",
 		Short: checkHelp,
 		Long:  checkHelp + packageHelp,
-		Args:  cobra.MinimumNArgs(1),
+		Args:  packageArgs,
 		RunE:  checkMain,
 	}
 
-	allowedLicenses []string
-	disallowedTypes []string
+	allowedLicenses         []string
+	disallowedTypes         []string
+	indirectAllowedLicenses []string
+	indirectDisallowedTypes []string
+	checkReplacements       bool
 )
 
 func init() {
 	checkCmd.Flags().StringSliceVar(&allowedLicenses, "allowed_licenses", []string{}, "list of allowed license names, can't be used in combination with disallowed_types")
 	checkCmd.Flags().StringSliceVar(&disallowedTypes, "disallowed_types", []string{}, "list of disallowed license types, can't be used in combination with allowed_licenses (default: forbidden, unknown)")
+	checkCmd.Flags().StringSliceVar(&indirectAllowedLicenses, "indirect_allowed_licenses", []string{}, "list of allowed license names for indirect dependencies, replacing allowed_licenses and disallowed_types for them")
+	checkCmd.Flags().StringSliceVar(&indirectDisallowedTypes, "indirect_disallowed_types", []string{}, "list of disallowed license types for indirect dependencies, replacing allowed_licenses and disallowed_types for them")
+	checkCmd.Flags().BoolVar(&checkReplacements, "check_replacements", false, "Report modules replaced by a local directory whose license differs from the upstream module's. The upstream module must be in the module cache.")
+
+	addSummaryFlag(checkCmd, "Print counts of libraries by license name, license type and restrictiveness, and of modules, after checking them.")
 
 	rootCmd.AddCommand(checkCmd)
 }
 
-func checkMain(_ *cobra.Command, args []string) error {
-	var disallowedLicenseTypes []licenses.Type
+// checkPolicy is the set of licenses allowed for a category of libraries.
+type checkPolicy struct {
+	allowedLicenseNames    []string
+	disallowedLicenseTypes []licenses.Type
+}
 
-	allowedLicenseNames := getAllowedLicenseNames()
-	disallowedLicenseTypes = getDisallowedLicenseTypes()
+// newCheckPolicy returns the policy given by lists of allowed license names
+// and disallowed license types, only one of which may be set. flagPrefix is the
+// prefix of the flags setting them.
+func newCheckPolicy(flagPrefix string, allowedLicenses, disallowedTypes []string) (checkPolicy, error) {
+	policy := checkPolicy{
+		allowedLicenseNames:    getAllowedLicenseNames(allowedLicenses),
+		disallowedLicenseTypes: getDisallowedLicenseTypes(disallowedTypes),
+	}
 
-	hasLicenseNames := len(allowedLicenseNames) > 0
-	hasLicenseType := len(disallowedLicenseTypes) > 0
+	hasLicenseNames := len(policy.allowedLicenseNames) > 0
+	hasLicenseType := len(policy.disallowedLicenseTypes) > 0
 
 	if hasLicenseNames && hasLicenseType {
-		return errors.New("allowed_licenses && disallowed_types can't be used at the same time")
+		return checkPolicy{}, fmt.Errorf("%sallowed_licenses && %sdisallowed_types can't be used at the same time", flagPrefix, flagPrefix)
 	}
 
 	if !hasLicenseNames && !hasLicenseType {
 		// fallback to original behaviour to avoid breaking changes
-		disallowedLicenseTypes = []licenses.Type{licenses.Forbidden, licenses.Unknown}
-		hasLicenseType = true
+		policy.disallowedLicenseTypes = []licenses.Type{licenses.Forbidden, licenses.Unknown}
+	}
+	return policy, nil
+}
+
+func checkMain(_ *cobra.Command, args []string) error {
+	if err := checkSummaryFormat(); err != nil {
+		return err
+	}
+	policy, err := newCheckPolicy("", allowedLicenses, disallowedTypes)
+	if err != nil {
+		return err
+	}
+	indirectPolicy := policy
+	if len(indirectAllowedLicenses) > 0 || len(indirectDisallowedTypes) > 0 {
+		if indirectPolicy, err = newCheckPolicy("indirect_", indirectAllowedLicenses, indirectDisallowedTypes); err != nil {
+			return err
+		}
 	}
 
 	classifier, err := licenses.NewClassifier()
@@ -72,7 +106,7 @@ func checkMain(_ *cobra.Command, args []string) error {
 		return err
 	}
 
-	libs, err := licenses.Libraries(context.Background(), classifier, includeTests, ignore, args...)
+	libs, err := loadLibraries(context.Background(), classifier, args)
 	if err != nil {
 		return err
 	}
@@ -81,17 +115,27 @@ func checkMain(_ *cobra.Command, args []string) error {
 	found := false
 
 	for _, lib := range libs {
+		if checkReplacements && lib.Module().IsLocalReplacement() {
+			if checkUpstreamLicenses(classifier, lib) {
+				found = true
+			}
+		}
+
 		if lib.LicenseFile == "" {
 			fmt.Fprintf(os.Stderr, "Did not find license for library '%v'.\n", lib)
 			found = true
 			continue
 		}
 
+		libPolicy := policy
+		if lib.Depth() > 1 {
+			libPolicy = indirectPolicy
+		}
 		for _, license := range lib.Licenses {
-			if hasLicenseNames && !isAllowedLicenseName(license.Name, allowedLicenseNames) {
+			if len(libPolicy.allowedLicenseNames) > 0 && !isAllowedLicenseName(license.Name, libPolicy.allowedLicenseNames) {
 				fmt.Fprintf(os.Stderr, "Not allowed license '%s' found for library '%v'.\n", license.Name, lib)
 				found = true
-			} else if hasLicenseType && isDisallowedLicenseType(license.Type, disallowedLicenseTypes) {
+			} else if len(libPolicy.disallowedLicenseTypes) > 0 && isDisallowedLicenseType(license.Type, libPolicy.disallowedLicenseTypes) {
 				fmt.Fprintf(
 					os.Stderr,
 					"License '%s' of not allowed license type '%s' found for library '%v'.\n",
@@ -103,14 +147,54 @@ func checkMain(_ *cobra.Command, args []string) error {
 		}
 	}
 
+	if summaryFormat != "" {
+		if err := summarize(libs).write(os.Stdout, summaryFormat); err != nil {
+			return err
+		}
+	}
+
 	if found {
-		os.Exit(1)
+		return errExitFailure
 	}
 
 	return nil
 }
 
-func getDisallowedLicenseTypes() []licenses.Type {
+// checkUpstreamLicenses reports whether the licenses of a library replaced by
+// a local directory differ from those of the upstream module it replaces.
+func checkUpstreamLicenses(classifier licenses.Classifier, lib *licenses.Library) bool {
+	original := lib.Module().Original
+	upstream, _, err := lib.UpstreamLicenses(context.Background(), classifier)
+	if err != nil {
+		klog.Warningf("Cannot compare the license of library '%v' with upstream module %s@%s: %v", lib, original.Path, original.Version, err)
+		return false
+	}
+	if got, want := licenseNames(lib.Licenses), licenseNames(upstream); !slices.Equal(got, want) {
+		fmt.Fprintf(
+			os.Stderr,
+			"License %q of library '%v' replaced by local directory %s differs from license %q of upstream module %s@%s.\n",
+			strings.Join(got, ", "),
+			lib,
+			lib.Module().Path,
+			strings.Join(want, ", "),
+			original.Path,
+			original.Version)
+		return true
+	}
+	return false
+}
+
+// licenseNames returns the sorted, unique names of licenses.
+func licenseNames(licenses []licenses.License) []string {
+	var names []string
+	for _, license := range licenses {
+		names = append(names, license.Name)
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+func getDisallowedLicenseTypes(disallowedTypes []string) []licenses.Type {
 	if len(disallowedTypes) == 0 {
 		return []licenses.Type{}
 	}
@@ -155,7 +239,7 @@ func isDisallowedLicenseType(licenseType licenses.Type, excludedLicenseTypes []l
 	return false
 }
 
-func getAllowedLicenseNames() []string {
+func getAllowedLicenseNames(allowedLicenses []string) []string {
 	if len(allowedLicenses) == 0 {
 		return []string{}
 	}
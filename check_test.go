@@ -0,0 +1,69 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-licenses/v2/licenses"
+)
+
+func TestNewCheckPolicy(t *testing.T) {
+	for _, test := range []struct {
+		desc            string
+		allowedLicenses []string
+		disallowedTypes []string
+		want            checkPolicy
+		wantErr         string
+	}{
+		{
+			desc: "Defaults to forbidden and unknown types",
+			want: checkPolicy{allowedLicenseNames: []string{}, disallowedLicenseTypes: []licenses.Type{licenses.Forbidden, licenses.Unknown}},
+		},
+		{
+			desc:            "Allowed license names",
+			allowedLicenses: []string{"MIT", " Apache-2.0"},
+			want:            checkPolicy{allowedLicenseNames: []string{"MIT", "Apache-2.0"}, disallowedLicenseTypes: []licenses.Type{}},
+		},
+		{
+			desc:            "Disallowed license types",
+			disallowedTypes: []string{"Reciprocal"},
+			want:            checkPolicy{allowedLicenseNames: []string{}, disallowedLicenseTypes: []licenses.Type{licenses.Reciprocal}},
+		},
+		{
+			desc:            "Both",
+			allowedLicenses: []string{"MIT"},
+			disallowedTypes: []string{"reciprocal"},
+			wantErr:         "indirect_allowed_licenses && indirect_disallowed_types can't be used at the same time",
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			got, err := newCheckPolicy("indirect_", test.allowedLicenses, test.disallowedTypes)
+			if test.wantErr != "" {
+				if err == nil || err.Error() != test.wantErr {
+					t.Fatalf("newCheckPolicy() = (_, %v), want (_, %q)", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newCheckPolicy() = (_, %v), want (_, nil)", err)
+			}
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(checkPolicy{})); diff != "" {
+				t.Errorf("newCheckPolicy() diff (-want +got): %s", diff)
+			}
+		})
+	}
+}
@@ -24,7 +24,7 @@ var (
 		Use:   "csv <package> [package...]",
 		Short: csvHelp,
 		Long:  csvHelp + packageHelp,
-		Args:  cobra.MinimumNArgs(1),
+		Args:  packageArgs,
 		RunE:  csvMain,
 	}
 )
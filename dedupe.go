@@ -0,0 +1,79 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+)
+
+const (
+	// dedupeSymlink replaces license files by symlinks to the shared texts.
+	dedupeSymlink = "symlink"
+	// dedupeIndex replaces license files by an index file naming the shared texts.
+	dedupeIndex = "index"
+
+	// sharedLicensesDir is the directory, relative to the save path, where
+	// deduplicated license texts are saved.
+	sharedLicensesDir = "_licenses"
+	// licenseIndexName is the name of the index file of a library directory.
+	licenseIndexName = "LICENSES.index"
+)
+
+// dedupe moves the content of the file saved at p to a shared file named after its
+// SHA-256 hash, and references the shared file from p's directory according to mode.
+// saved lists the paths saved for the library, it is returned updated accordingly.
+func (files saveFiles) dedupe(p, mode string, saved []string) ([]string, error) {
+	f, ok := files[p]
+	if !ok {
+		return saved, nil
+	}
+	sum, err := fileSHA256(f)
+	if err != nil {
+		return nil, err
+	}
+	shared := path.Join(sharedLicensesDir, sum+".txt")
+	files[shared] = f
+
+	dir := path.Dir(p)
+	var ref string
+	switch mode {
+	case dedupeSymlink:
+		target, err := filepath.Rel(filepath.FromSlash(dir), filepath.FromSlash(shared))
+		if err != nil {
+			return nil, err
+		}
+		files[p] = saveFile{src: f.src, data: f.data, link: filepath.ToSlash(target)}
+		ref = p
+	case dedupeIndex:
+		delete(files, p)
+		ref = path.Join(dir, licenseIndexName)
+		index := files[ref]
+		index.data = append(index.data, fmt.Sprintf("%s %s\n", path.Base(p), shared)...)
+		files[ref] = index
+	default:
+		return nil, fmt.Errorf("unsupported dedupe mode %q", mode)
+	}
+
+	updated := []string{shared}
+	for _, s := range saved {
+		if s == p {
+			s = ref
+		}
+		updated = append(updated, s)
+	}
+	return updated, nil
+}
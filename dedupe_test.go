@@ -0,0 +1,72 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSaveFilesDedupe(t *testing.T) {
+	// SHA-256 of "MIT license\n".
+	const shared = "_licenses/456db14903330c8ebc89f5e4055dd03df6e5b70fecb4bd1320a69642ba71039c.txt"
+	mit := []byte("MIT license\n")
+
+	for _, test := range []struct {
+		mode      string
+		wantFiles saveFiles
+		wantSaved []string
+	}{
+		{
+			mode: dedupeSymlink,
+			wantFiles: saveFiles{
+				shared:                    {data: mit},
+				"example.com/a/LICENSE":   {data: mit, link: "../../" + shared},
+				"example.com/a/b/LICENSE": {data: mit, link: "../../../" + shared},
+			},
+			wantSaved: []string{shared, "example.com/a/b/LICENSE"},
+		},
+		{
+			mode: dedupeIndex,
+			wantFiles: saveFiles{
+				shared:                                {data: mit},
+				"example.com/a/" + licenseIndexName:   {data: []byte("LICENSE " + shared + "\n")},
+				"example.com/a/b/" + licenseIndexName: {data: []byte("LICENSE " + shared + "\n")},
+			},
+			wantSaved: []string{shared, "example.com/a/b/" + licenseIndexName},
+		},
+	} {
+		t.Run(test.mode, func(t *testing.T) {
+			files := saveFiles{
+				"example.com/a/LICENSE":   {data: mit},
+				"example.com/a/b/LICENSE": {data: mit},
+			}
+			if _, err := files.dedupe("example.com/a/LICENSE", test.mode, []string{"example.com/a/LICENSE"}); err != nil {
+				t.Fatalf("dedupe() = (_, %v), want (_, nil)", err)
+			}
+			saved, err := files.dedupe("example.com/a/b/LICENSE", test.mode, []string{"example.com/a/b/LICENSE"})
+			if err != nil {
+				t.Fatalf("dedupe() = (_, %v), want (_, nil)", err)
+			}
+			if diff := cmp.Diff(test.wantFiles, files, cmp.AllowUnexported(saveFile{})); diff != "" {
+				t.Errorf("files diff (-want +got): %s", diff)
+			}
+			if diff := cmp.Diff(test.wantSaved, saved); diff != "" {
+				t.Errorf("saved diff (-want +got): %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/google/go-licenses/v2/licenses"
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"k8s.io/klog/v2"
+)
+
+var (
+	diffHelp = "Reports license changes of modules across versions."
+	diffCmd  = &cobra.Command{
+		Use:   "diff <module> <old_version> <new_version>",
+		Short: diffHelp,
+		Long: diffHelp + `
+
+Either compare two versions of a module, or compare all the modules required with
+different versions by two go.mod files, using --old_go_mod and --new_go_mod.
+Replace directives of the go.mod files are applied, and the licenses of modules
+added by the new go.mod file are reported too. Removed modules and modules
+replaced by a local directory are not compared. Modules are read from the module
+cache, or from a GOPROXY-style directory with --proxy_dir. Exits with status 1
+when a license changed or a module was added.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if oldGoMod != "" || newGoMod != "" {
+				if oldGoMod == "" || newGoMod == "" {
+					return errors.New("--old_go_mod and --new_go_mod must be used together")
+				}
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(3)(cmd, args)
+		},
+		RunE: diffMain,
+	}
+
+	oldGoMod string
+	newGoMod string
+)
+
+func init() {
+	diffCmd.Flags().StringVar(&oldGoMod, "old_go_mod", "", "go.mod file before the change.")
+	diffCmd.Flags().StringVar(&newGoMod, "new_go_mod", "", "go.mod file after the change.")
+
+	rootCmd.AddCommand(diffCmd)
+}
+
+// versionChange is a change of the module version used for a required module,
+// after replace directives. Old is the zero module.Version when the module was
+// added.
+type versionChange struct {
+	Path     string
+	Old, New module.Version
+}
+
+// describe returns the version of m, or its path and version when m replaces
+// the required module.
+func (c versionChange) describe(m module.Version) string {
+	if m.Path == c.Path {
+		return m.Version
+	}
+	return m.String()
+}
+
+func diffMain(_ *cobra.Command, args []string) error {
+	var changes []versionChange
+	if len(args) == 3 {
+		changes = []versionChange{{
+			Path: args[0],
+			Old:  module.Version{Path: args[0], Version: args[1]},
+			New:  module.Version{Path: args[0], Version: args[2]},
+		}}
+	} else {
+		var err error
+		if changes, err = goModChanges(oldGoMod, newGoMod); err != nil {
+			return err
+		}
+	}
+
+	classifier, err := licenses.NewClassifier()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	identify := func(m module.Version) ([]licenses.License, error) {
+		lic, err := licenses.IdentifyModuleVersion(ctx, classifier, licenses.Options{}, proxyDir, m.Path, m.Version)
+		if err != nil {
+			return nil, err
+		}
+		return lic.Licenses, nil
+	}
+	changed, added := 0, 0
+	var errs []error
+	for _, c := range changes {
+		if c.New.Version == "" || (c.Old.Path != "" && c.Old.Version == "") {
+			klog.Warningf("Skipping %s: replaced by a local directory", c.Path)
+			continue
+		}
+		newLicenses, err := identify(c.New)
+		if err != nil {
+			klog.Error(err)
+			errs = append(errs, err)
+			continue
+		}
+		if c.Old == (module.Version{}) {
+			added++
+			fmt.Printf("%s %s: added with license %s\n", c.Path, c.describe(c.New), describeLicenses(newLicenses))
+			continue
+		}
+		oldLicenses, err := identify(c.Old)
+		if err != nil {
+			klog.Error(err)
+			errs = append(errs, err)
+			continue
+		}
+		if !slices.Equal(oldLicenses, newLicenses) {
+			changed++
+			fmt.Printf("%s %s -> %s: license changed from %s to %s\n", c.Path, c.describe(c.Old), c.describe(c.New), describeLicenses(oldLicenses), describeLicenses(newLicenses))
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Compared %d modules: %d license changes, %d added modules.\n", len(changes), changed, added)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("cannot compare the licenses of %d modules", len(errs))
+	}
+	if changed > 0 || added > 0 {
+		return errExitFailure
+	}
+	return nil
+}
+
+// goModChanges returns the modules required by the go.mod file at newPath that
+// are added or used with a different version, after replace directives, since
+// the go.mod file at oldPath. Removed modules are not returned.
+func goModChanges(oldPath, newPath string) ([]versionChange, error) {
+	oldVersions, err := goModRequirements(oldPath)
+	if err != nil {
+		return nil, err
+	}
+	newVersions, err := goModRequirements(newPath)
+	if err != nil {
+		return nil, err
+	}
+	var changes []versionChange
+	for path, newVersion := range newVersions {
+		if oldVersion := oldVersions[path]; oldVersion != newVersion {
+			changes = append(changes, versionChange{Path: path, Old: oldVersion, New: newVersion})
+		}
+	}
+	slices.SortFunc(changes, func(a, b versionChange) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return changes, nil
+}
+
+// goModRequirements returns the modules used for the modules required by the
+// go.mod file at path, after its replace directives, by required module path.
+// Local replacements have no version.
+func goModRequirements(path string) (map[string]module.Version, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := modfile.Parse(path, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	replacements := map[module.Version]module.Version{}
+	for _, r := range f.Replace {
+		replacements[r.Old] = r.New
+	}
+	versions := map[string]module.Version{}
+	for _, r := range f.Require {
+		m := r.Mod
+		// A replace directive with a version takes precedence over one without.
+		if replacement, ok := replacements[r.Mod]; ok {
+			m = replacement
+		} else if replacement, ok := replacements[module.Version{Path: r.Mod.Path}]; ok {
+			m = replacement
+		}
+		versions[r.Mod.Path] = m
+	}
+	return versions, nil
+}
+
+// describeLicenses returns the names and types of licenses, e.g. "MIT (notice)".
+func describeLicenses(lics []licenses.License) string {
+	if len(lics) == 0 {
+		return UNKNOWN
+	}
+	var descriptions []string
+	for _, license := range lics {
+		descriptions = append(descriptions, fmt.Sprintf("%s (%s)", license.Name, license.Type))
+	}
+	return strings.Join(descriptions, ", ")
+}
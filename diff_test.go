@@ -0,0 +1,97 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/mod/module"
+)
+
+func TestGoModChanges(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.mod")
+	newPath := filepath.Join(dir, "new.mod")
+	if err := os.WriteFile(oldPath, []byte(`module example.com/app
+
+require (
+	example.com/same v1.0.0
+	example.com/bumped v1.0.0
+	example.com/removed v1.0.0
+	example.com/forked v1.0.0
+	example.com/pinned v1.0.0
+	example.com/local v1.0.0
+)
+
+replace example.com/forked => example.com/fork v1.0.0
+
+replace example.com/pinned v1.0.0 => example.com/fork v1.0.0
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(newPath, []byte(`module example.com/app
+
+require (
+	example.com/same v1.0.0
+	example.com/bumped v1.1.0+incompatible
+	example.com/added v1.0.0
+	example.com/forked v1.0.0
+	example.com/pinned v1.1.0
+	example.com/local v1.0.0
+)
+
+replace example.com/forked => example.com/fork v1.1.0
+
+replace (
+	example.com/pinned => example.com/other v2.0.0
+	example.com/pinned v1.1.0 => example.com/fork v1.0.0
+)
+
+replace example.com/local => ./local
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := goModChanges(oldPath, newPath)
+	if err != nil {
+		t.Fatalf("goModChanges() = (_, %v), want (_, nil)", err)
+	}
+	want := []versionChange{
+		{
+			Path: "example.com/added",
+			New:  module.Version{Path: "example.com/added", Version: "v1.0.0"},
+		},
+		{
+			Path: "example.com/bumped",
+			Old:  module.Version{Path: "example.com/bumped", Version: "v1.0.0"},
+			New:  module.Version{Path: "example.com/bumped", Version: "v1.1.0+incompatible"},
+		},
+		{
+			Path: "example.com/forked",
+			Old:  module.Version{Path: "example.com/fork", Version: "v1.0.0"},
+			New:  module.Version{Path: "example.com/fork", Version: "v1.1.0"},
+		},
+		{
+			Path: "example.com/local",
+			Old:  module.Version{Path: "example.com/local", Version: "v1.0.0"},
+			New:  module.Version{Path: "./local"},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("goModChanges() diff (-want +got): %s", diff)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -214,6 +215,42 @@ func TestCheckCommandE2E(t *testing.T) {
 	}
 }
 
+func TestCheckProxyDirE2E(t *testing.T) {
+	tempDir := t.TempDir()
+	goLicensesPath := filepath.Join(tempDir, "go-licenses")
+	if out, err := exec.Command("go", "build", "-o", goLicensesPath).CombinedOutput(); err != nil {
+		t.Fatalf("building go-licenses:\n%s", out)
+	}
+
+	// hello01 requires no module, so an empty proxy dir is enough.
+	proxyDir := t.TempDir()
+	tmpDir := t.TempDir()
+	cmd := exec.Command(goLicensesPath, "check", "--proxy_dir="+proxyDir, "--disallowed_types=notice")
+	cmd.Dir = "testdata/modules/hello01"
+	cmd.Env = append(os.Environ(), "TMPDIR="+tmpDir)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitError *exec.ExitError
+	if !errors.As(err, &exitError) || exitError.ExitCode() != 1 {
+		t.Fatalf("go-licenses check --proxy_dir = %v, want exit code 1\n%s", err, stderr.String())
+	}
+	want := "License 'Apache-2.0' of not allowed license type 'Notice' found for library 'github.com/google/go-licenses/testdata/modules/hello01'."
+	if !strings.Contains(stderr.String(), want) {
+		t.Errorf("go-licenses check --proxy_dir logged\n%s\nwant it to contain\n%s", stderr.String(), want)
+	}
+
+	// The license files are extracted to a temporary directory, which must be
+	// removed even though check fails.
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) > 0 {
+		t.Errorf("go-licenses check --proxy_dir left %s in the temporary directory", entries[0].Name())
+	}
+}
+
 func filterOutput(output string) string {
 	output = regexp.MustCompile(`(?m)W\d+.*\n`).
 		ReplaceAllString(output, "")
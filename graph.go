@@ -0,0 +1,165 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/google/go-licenses/v2/licenses"
+	"github.com/spf13/cobra"
+)
+
+var (
+	graphHelp = "Prints the dependency graph of one or more Go packages, annotated with licenses."
+	graphCmd  = &cobra.Command{
+		Use:   "graph <package> [package...]",
+		Short: graphHelp,
+		Long:  graphHelp + packageHelp,
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  graphMain,
+	}
+
+	graphFormat string
+)
+
+func init() {
+	graphCmd.Flags().StringVar(&graphFormat, "format", "dot", "Format of the graph: dot (Graphviz) or json.")
+
+	rootCmd.AddCommand(graphCmd)
+}
+
+// restrictivenessColors are the Graphviz fill colors of libraries, by the
+// restrictiveness of their licenses.
+var restrictivenessColors = map[licenses.LicenseRestrictiveness]string{
+	licenses.RestrictionsShareLicense: "palegreen",
+	licenses.RestrictionsShareCode:    "orange",
+	licenses.RestrictionsNotAllowed:   "tomato",
+	licenses.RestrictionsUnknown:      "lightgray",
+}
+
+// dependencyGraph is the graph of imports between libraries.
+type dependencyGraph struct {
+	Nodes []graphNode `json:"nodes"`
+	Edges []graphEdge `json:"edges"`
+}
+
+type graphNode struct {
+	Name            string                          `json:"name"`
+	Version         string                          `json:"version,omitempty"`
+	Licenses        []manifestLicense               `json:"licenses"`
+	Restrictiveness licenses.LicenseRestrictiveness `json:"restrictiveness"`
+}
+
+// graphEdge means that a package in library From imports a package in library To.
+type graphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func graphMain(_ *cobra.Command, args []string) error {
+	if graphFormat != "dot" && graphFormat != "json" {
+		return fmt.Errorf("invalid --format %q: want dot or json", graphFormat)
+	}
+	if proxyDir != "" {
+		return errors.New("graph can't be used with --proxy_dir, which doesn't load the imports between packages")
+	}
+
+	classifier, err := licenses.NewClassifier()
+	if err != nil {
+		return err
+	}
+
+	libs, err := loadLibraries(context.Background(), classifier, args)
+	if err != nil {
+		return err
+	}
+
+	graph := newDependencyGraph(libs)
+	if graphFormat == "json" {
+		return graph.writeJSON(os.Stdout)
+	}
+	return graph.writeDOT(os.Stdout)
+}
+
+func newDependencyGraph(libs []*licenses.Library) dependencyGraph {
+	graph := dependencyGraph{Nodes: []graphNode{}, Edges: []graphEdge{}}
+	for _, lib := range libs {
+		node := graphNode{
+			Name:     lib.Name(),
+			Version:  lib.Version(),
+			Licenses: []manifestLicense{},
+		}
+		var licenseTypes []licenses.Type
+		for _, license := range lib.Licenses {
+			node.Licenses = append(node.Licenses, manifestLicense{
+				Name: license.Name,
+				Type: license.Type.String(),
+			})
+			licenseTypes = append(licenseTypes, license.Type)
+		}
+		node.Restrictiveness = licenses.LicenseTypeRestrictiveness(licenseTypes...)
+		graph.Nodes = append(graph.Nodes, node)
+
+		for _, imported := range lib.Imports() {
+			graph.Edges = append(graph.Edges, graphEdge{From: lib.Name(), To: imported.Name()})
+		}
+	}
+	return graph
+}
+
+func (g dependencyGraph) writeJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(g)
+}
+
+// writeDOT writes the graph in the Graphviz DOT language. Each library is
+// labeled with its licenses and filled with the color of their restrictiveness.
+func (g dependencyGraph) writeDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph dependencies {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=filled];\n")
+	for _, node := range g.Nodes {
+		label := node.Name
+		if node.Version != "" {
+			label += "@" + node.Version
+		}
+		if len(node.Licenses) == 0 {
+			label += "\n" + UNKNOWN
+		}
+		for _, license := range node.Licenses {
+			label += fmt.Sprintf("\n%s (%s)", license.Name, license.Type)
+		}
+		fmt.Fprintf(&b, "  %s [label=%s, fillcolor=%s];\n", dotQuote(node.Name), dotQuote(label), restrictivenessColors[node.Restrictiveness])
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(edge.From), dotQuote(edge.To))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotQuote returns s as a DOT quoted string, with newlines as line breaks.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-licenses/v2/licenses"
+)
+
+func TestDependencyGraphWriteDOT(t *testing.T) {
+	graph := dependencyGraph{
+		Nodes: []graphNode{
+			{
+				Name:            "example.com/app",
+				Licenses:        []manifestLicense{{Name: "Apache-2.0", Type: "notice"}},
+				Restrictiveness: licenses.RestrictionsShareLicense,
+			},
+			{
+				Name:            "example.com/gpl",
+				Version:         "v1.0.0",
+				Licenses:        []manifestLicense{{Name: "GPL-3.0", Type: "restricted"}},
+				Restrictiveness: licenses.RestrictionsShareCode,
+			},
+			{
+				Name:            `example.com/"odd"`,
+				Licenses:        []manifestLicense{},
+				Restrictiveness: licenses.RestrictionsUnknown,
+			},
+		},
+		Edges: []graphEdge{
+			{From: "example.com/app", To: "example.com/gpl"},
+			{From: "example.com/gpl", To: `example.com/"odd"`},
+		},
+	}
+	var got strings.Builder
+	if err := graph.writeDOT(&got); err != nil {
+		t.Fatalf("writeDOT() = %v, want nil", err)
+	}
+	want := `digraph dependencies {
+  rankdir=LR;
+  node [shape=box, style=filled];
+  "example.com/app" [label="example.com/app\nApache-2.0 (notice)", fillcolor=palegreen];
+  "example.com/gpl" [label="example.com/gpl@v1.0.0\nGPL-3.0 (restricted)", fillcolor=orange];
+  "example.com/\"odd\"" [label="example.com/\"odd\"\nUnknown", fillcolor=lightgray];
+  "example.com/app" -> "example.com/gpl";
+  "example.com/gpl" -> "example.com/\"odd\"";
+}
+`
+	if diff := cmp.Diff(want, got.String()); diff != "" {
+		t.Errorf("writeDOT() diff (-want +got): %s", diff)
+	}
+}
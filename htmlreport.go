@@ -0,0 +1,124 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+)
+
+// htmlReportTemplate renders a self-contained page, with inline style and
+// script, so that it can be embedded as is in an application.
+var htmlReportTemplate = template.Must(template.New("").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="generator" content="go-licenses {{.ToolVersion}}">
+<title>Open source licenses</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 1em auto; max-width: 60em; padding: 0 1em; line-height: 1.4; }
+input[type=search] { box-sizing: border-box; font-size: 1em; padding: .4em; width: 100%; }
+ul { padding-left: 1.2em; }
+li.library { margin: .3em 0; }
+.version { color: #666; }
+details pre { background: #f6f6f6; font-size: .85em; max-height: 30em; overflow: auto; padding: .5em; white-space: pre-wrap; }
+.hidden { display: none; }
+</style>
+</head>
+<body>
+<h1>Open source licenses</h1>
+<p>This software uses the following {{len .Libraries}} open source libraries.</p>
+<input type="search" id="search" placeholder="Search libraries and licenses" aria-label="Search libraries and licenses">
+<ul>
+{{- range .Groups}}
+<li><a href="#{{.ID}}">{{.License}}</a> ({{len .Libraries}})</li>
+{{- end}}
+</ul>
+{{- range .Groups}}
+<section class="license" id="{{.ID}}">
+<h2>{{.License}}</h2>
+<ul>
+{{- range .Libraries}}
+<li class="library" data-search="{{.Name}} {{.Version}} {{range .LicenseNames}}{{.}} {{end}}">
+{{- if ne .LicenseURL "Unknown"}}<a href="{{.LicenseURL}}">{{.Name}}</a>{{else}}{{.Name}}{{end}}
+{{- if ne .Version "Unknown"}} <span class="version">{{.Version}}</span>{{end}}
+{{- with .LicenseText}}
+<details><summary>License text</summary><pre>{{.}}</pre></details>
+{{- end}}
+</li>
+{{- end}}
+</ul>
+</section>
+{{- end}}
+<script>
+document.getElementById("search").addEventListener("input", function (e) {
+  var query = e.target.value.toLowerCase();
+  document.querySelectorAll("section.license").forEach(function (section) {
+    var visible = 0;
+    section.querySelectorAll("li.library").forEach(function (li) {
+      var match = li.dataset.search.toLowerCase().indexOf(query) >= 0;
+      li.classList.toggle("hidden", !match);
+      if (match) visible++;
+    });
+    section.classList.toggle("hidden", visible === 0);
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+// htmlLicenseGroup is the libraries with a license in the HTML report.
+type htmlLicenseGroup struct {
+	License   string
+	ID        string
+	Libraries []libraryData
+}
+
+// reportHTML writes the libraries as an HTML page, grouped by license name.
+// A library with several licenses is in the group of each of them.
+func reportHTML(w io.Writer, libs []libraryData) error {
+	groups := map[string]*htmlLicenseGroup{}
+	for _, lib := range libs {
+		names := lib.LicenseNames
+		if len(names) == 0 {
+			names = []string{UNKNOWN}
+		}
+		for _, name := range names {
+			if groups[name] == nil {
+				groups[name] = &htmlLicenseGroup{License: name, ID: "license-" + strings.ToLower(name)}
+			}
+			groups[name].Libraries = append(groups[name].Libraries, lib)
+		}
+	}
+	data := struct {
+		ToolVersion string
+		Libraries   []libraryData
+		Groups      []*htmlLicenseGroup
+	}{
+		ToolVersion: toolVersion(),
+		Libraries:   libs,
+	}
+	for _, g := range groups {
+		data.Groups = append(data.Groups, g)
+	}
+	sort.Slice(data.Groups, func(i, j int) bool {
+		return data.Groups[i].License < data.Groups[j].License
+	})
+	return htmlReportTemplate.Execute(w, data)
+}
@@ -0,0 +1,80 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReportHTML(t *testing.T) {
+	licensePath := filepath.Join(t.TempDir(), "LICENSE")
+	if err := os.WriteFile(licensePath, []byte("Copyright <script>alert(1)</script> Foo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	libs := []libraryData{
+		{
+			Name:         "example.com/dual",
+			Version:      "v1.0.0",
+			LicensePath:  licensePath,
+			LicenseURL:   "https://example.com/dual/LICENSE",
+			LicenseNames: []string{"MIT", "Apache-2.0"},
+		},
+		{
+			Name:        "example.com/none",
+			Version:     UNKNOWN,
+			LicensePath: UNKNOWN,
+			LicenseURL:  UNKNOWN,
+		},
+	}
+	var got strings.Builder
+	if err := reportHTML(&got, libs); err != nil {
+		t.Fatalf("reportHTML() = %v", err)
+	}
+	html := got.String()
+
+	// Groups are sorted by license name, and a library is in each of its groups.
+	var positions []int
+	for _, want := range []string{`id="license-apache-2.0"`, `id="license-mit"`, `id="license-unknown"`} {
+		i := strings.Index(html, want)
+		if i < 0 {
+			t.Fatalf("reportHTML() has no %s:\n%s", want, html)
+		}
+		positions = append(positions, i)
+	}
+	for i := 1; i < len(positions); i++ {
+		if positions[i] < positions[i-1] {
+			t.Errorf("reportHTML() groups are not sorted by license name:\n%s", html)
+		}
+	}
+	if n := strings.Count(html, `<a href="https://example.com/dual/LICENSE">example.com/dual</a>`); n != 2 {
+		t.Errorf("reportHTML() links example.com/dual %d times, want 2:\n%s", n, html)
+	}
+	for _, want := range []string{
+		"This software uses the following 2 open source libraries.",
+		`<span class="version">v1.0.0</span>`,
+		"<details><summary>License text</summary><pre>Copyright &lt;script&gt;alert(1)&lt;/script&gt; Foo</pre></details>",
+		"example.com/none\n</li>",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("reportHTML() doesn't contain %q:\n%s", want, html)
+		}
+	}
+	if strings.Contains(html, "<script>alert") {
+		t.Errorf("reportHTML() doesn't escape the license text:\n%s", html)
+	}
+}
@@ -14,6 +14,11 @@
 
 package source
 
+import (
+	"context"
+	"net/http"
+)
+
 // This file includes all local additions to source package for google/go-licenses use-cases.
 
 // SetCommit overrides commit to a specified commit. Usually, you should pass your version to
@@ -31,3 +36,15 @@ func (i *Info) SetCommit(commit string) {
 	}
 	i.commit = commit
 }
+
+// URLExists reports whether url can be reached, by issuing a HEAD request for
+// it. A non-200 response is not an error, it only means that the URL does not
+// exist. Errors are returned when the request itself fails.
+func (c *Client) URLExists(ctx context.Context, url string) (bool, error) {
+	resp, err := c.doURL(ctx, http.MethodHead, url, false)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK, nil
+}
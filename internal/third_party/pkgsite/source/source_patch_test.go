@@ -0,0 +1,55 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestURLExists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("got %s request, want HEAD", r.Method)
+		}
+		if r.URL.Path != "/LICENSE" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := &Client{httpClient: server.Client()}
+
+	for _, test := range []struct {
+		url  string
+		want bool
+	}{
+		{server.URL + "/LICENSE", true},
+		{server.URL + "/missing/LICENSE", false},
+	} {
+		got, err := client.URLExists(context.Background(), test.url)
+		if err != nil {
+			t.Fatalf("URLExists(%q) = (_, %v), want (_, nil)", test.url, err)
+		}
+		if got != test.want {
+			t.Errorf("URLExists(%q) = %t, want %t", test.url, got, test.want)
+		}
+	}
+
+	if _, err := NewClientForTesting().URLExists(context.Background(), server.URL); err == nil {
+		t.Errorf("URLExists with a nil http client = (_, nil), want error")
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+var (
+	// bundledDirRegexp matches the names of directories commonly holding
+	// bundled third-party code that is not made of Go packages.
+	bundledDirRegexp = regexp.MustCompile(`^(?i)(third[_-]?party|external|bundled?|deps)$`)
+
+	// headerExtensions are the extensions of non-Go source files whose license
+	// header is classified.
+	headerExtensions = []string{".c", ".h", ".cc", ".cpp", ".hpp", ".m", ".s", ".js", ".mjs", ".ts", ".css", ".html", ".py", ".sh"}
+)
+
+// assetPackage holds the non-Go files of a package that may carry their own license.
+type assetPackage struct {
+	pkgPath string
+	pkgDir  string
+	// assets are the paths of the non-Go source files and embedded files of the package.
+	assets []string
+}
+
+// findAssetLibraries returns the sub-libraries of lib made of the assets of its
+// packages, i.e. non-Go source files, embedded files and bundled third-party
+// directories, that are covered by a license of their own:
+//   - license files in directories below a package directory, e.g.
+//     static/jquery/LICENSE, cover the assets in that directory;
+//   - other assets are classified themselves, to find licenses in their headers.
+//
+// Files in usedLicenseFiles are already the license of a library and are skipped.
+func findAssetLibraries(classifier Classifier, logger Logger, lib *Library, pkgs []assetPackage, usedLicenseFiles map[string]bool) []*Library {
+	identify := func(path string) []License {
+		licenses, err := classifier.Identify(path)
+		if err != nil {
+			logger.Errorf("Failed to parse %s: %v", path, err)
+		}
+		return licenses
+	}
+
+	var subLibs []*Library
+	for _, pkg := range pkgs {
+		assets := append([]string{}, pkg.assets...)
+		// Bundled third-party directories are scanned as a whole.
+		entries, err := os.ReadDir(pkg.pkgDir)
+		if err != nil {
+			logger.Warningf("Failed to read package directory %s: %v", pkg.pkgDir, err)
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() || !bundledDirRegexp.MatchString(entry.Name()) {
+				continue
+			}
+			err := filepath.WalkDir(filepath.Join(pkg.pkgDir, entry.Name()), func(p string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if d.Type().IsRegular() {
+					assets = append(assets, p)
+				}
+				return nil
+			})
+			if err != nil {
+				logger.Warningf("Failed to read bundled directory: %v", err)
+			}
+		}
+		sort.Strings(assets)
+		assets = slices.Compact(assets)
+
+		// Find the closest license file below the package directory for each asset.
+		covered := map[string][]string{}
+		var uncovered []string
+		for _, asset := range assets {
+			if licenseRegexp.MatchString(filepath.Base(asset)) {
+				continue
+			}
+			candidates, err := findAllUpwards(filepath.Dir(asset), licenseRegexp, pkg.pkgDir+string(filepath.Separator))
+			if err != nil {
+				logger.Warningf("Failed to find license of %s: %v", asset, err)
+				continue
+			}
+			licenseFile := ""
+			for _, candidate := range candidates {
+				if !usedLicenseFiles[candidate] {
+					licenseFile = candidate
+					break
+				}
+			}
+			if licenseFile == "" {
+				uncovered = append(uncovered, asset)
+			} else {
+				covered[licenseFile] = append(covered[licenseFile], asset)
+			}
+		}
+
+		for licenseFile, files := range covered {
+			licenses := identify(licenseFile)
+			if len(licenses) == 0 {
+				uncovered = append(uncovered, files...)
+				continue
+			}
+			usedLicenseFiles[licenseFile] = true
+			subLibs = append(subLibs, newAssetLibrary(lib, pkg, filepath.Dir(licenseFile), licenseFile, licenses, files))
+		}
+
+		// Assets not covered by a license file may have a license header.
+		for _, asset := range uncovered {
+			if !slices.Contains(headerExtensions, strings.ToLower(filepath.Ext(asset))) {
+				continue
+			}
+			licenses := identify(asset)
+			if len(licenses) == 0 || sameLicenseNames(licenses, lib.Licenses) {
+				continue
+			}
+			subLibs = append(subLibs, newAssetLibrary(lib, pkg, asset, asset, licenses, []string{asset}))
+		}
+	}
+	return subLibs
+}
+
+// newAssetLibrary returns the sub-library of parent for the assets at or under
+// assetPath in pkg, named after the package and assetPath.
+func newAssetLibrary(parent *Library, pkg assetPackage, assetPath, licenseFile string, licenses []License, files []string) *Library {
+	name := pkg.pkgPath
+	if rel, err := filepath.Rel(pkg.pkgDir, assetPath); err == nil {
+		name = path.Join(name, filepath.ToSlash(rel))
+	}
+	sort.Strings(files)
+	return &Library{
+		LicenseFile: licenseFile,
+		Licenses:    licenses,
+		Packages:    []string{name},
+		module:      parent.module,
+		sourceFiles: files,
+		depth:       parent.depth,
+		parent:      parent,
+	}
+}
+
+// sameLicenseNames returns true if a and b have the same license names.
+func sameLicenseNames(a, b []License) bool {
+	names := func(licenses []License) []string {
+		var names []string
+		for _, license := range licenses {
+			names = append(names, license.Name)
+		}
+		sort.Strings(names)
+		return slices.Compact(names)
+	}
+	return slices.Equal(names(a), names(b))
+}
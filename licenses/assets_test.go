@@ -0,0 +1,98 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadLibrariesScanAssets(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Cannot get working directory: %v", err)
+	}
+	classifier := classifierStub{
+		licenses: map[string][]License{
+			"testdata/LICENSE":                         {{Name: "foo", Type: Notice}},
+			"testdata/assets/static/header.js":         {{Name: "BSD-3-Clause", Type: Notice}},
+			"testdata/assets/static/lib/LICENSE":       {{Name: "MIT", Type: Notice}},
+			"testdata/assets/third_party/font/LICENSE": {{Name: "OFL-1.1", Type: Notice}},
+		},
+	}
+	importPath := "github.com/google/go-licenses/v2/licenses/testdata/assets"
+	opts := Options{ScanAssets: true, Logger: &recordingLogger{}}
+	gotLibs, err := LoadLibraries(context.Background(), classifier, opts, importPath)
+	if err != nil {
+		t.Fatalf("LoadLibraries(_, %q) = (_, %q), want (_, nil)", importPath, err)
+	}
+
+	type libInfo struct {
+		Name        string
+		LicenseFile string
+		Licenses    []License
+		SourceFiles []string
+		Parent      string
+	}
+	var got []libInfo
+	for _, lib := range gotLibs {
+		info := libInfo{
+			Name:        lib.Name(),
+			LicenseFile: lib.LicenseFile,
+			Licenses:    lib.Licenses,
+			SourceFiles: lib.SourceFiles(),
+		}
+		if lib.Parent() != nil {
+			info.Parent = lib.Parent().Name()
+		}
+		got = append(got, info)
+	}
+	dir := wd + "/testdata/assets"
+	want := []libInfo{
+		{
+			Name:        importPath,
+			LicenseFile: wd + "/testdata/LICENSE",
+			Licenses:    classifier.licenses["testdata/LICENSE"],
+			SourceFiles: []string{dir + "/assets.go", dir + "/static/header.js", dir + "/static/lib/LICENSE", dir + "/static/lib/x.js"},
+		},
+		{
+			Name:        importPath + "/static/header.js",
+			LicenseFile: dir + "/static/header.js",
+			Licenses:    classifier.licenses["testdata/assets/static/header.js"],
+			SourceFiles: []string{dir + "/static/header.js"},
+			Parent:      importPath,
+		},
+		{
+			Name:        importPath + "/static/lib",
+			LicenseFile: dir + "/static/lib/LICENSE",
+			Licenses:    classifier.licenses["testdata/assets/static/lib/LICENSE"],
+			SourceFiles: []string{dir + "/static/lib/x.js"},
+			Parent:      importPath,
+		},
+		{
+			Name:        importPath + "/third_party/font",
+			LicenseFile: dir + "/third_party/font/LICENSE",
+			Licenses:    classifier.licenses["testdata/assets/third_party/font/LICENSE"],
+			SourceFiles: []string{dir + "/third_party/font/font.ttf"},
+			Parent:      importPath,
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("LoadLibraries(_, %q) diff (-want +got): %s", importPath, diff)
+	}
+}
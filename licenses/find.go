@@ -19,13 +19,29 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 )
 
 var (
 	licenseRegexp = regexp.MustCompile(`^(?i)((UN)?LICEN(S|C)E|COPYING|README|NOTICE).*$`)
+	noticeRegexp  = regexp.MustCompile(`^(?i)NOTICE`)
+	readmeRegexp  = regexp.MustCompile(`^(?i)README`)
+
+	// DefaultNoticeNames are the names of files treated as copyright notices by default.
+	DefaultNoticeNames = []string{"NOTICE", "NOTICES", "AUTHORS", "CONTRIBUTORS", "THIRD_PARTY_NOTICES"}
 )
 
+// NoticeRegexp returns a regexp matching file names that are one of names, ignoring
+// case, optionally followed by a .txt, .md or .rst extension.
+func NoticeRegexp(names ...string) *regexp.Regexp {
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, regexp.QuoteMeta(name))
+	}
+	return regexp.MustCompile(`^(?i)(` + strings.Join(quoted, "|") + `)(\.(txt|md|rst))?$`)
+}
+
 // FindCandidates returns the candidate file path of the license for this package.
 //
 // dir is path of the directory where we want to find a license.
@@ -49,6 +65,53 @@ func FindCandidates(dir string, rootDir string) ([]string, error) {
 	return findAllUpwards(dir, licenseRegexp, rootDir)
 }
 
+// CandidateKind is the kind of a license file candidate, given by its name.
+// Kinds are ordered by preference: license files are preferred to notices,
+// which are preferred to READMEs, which may only mention a license.
+type CandidateKind int
+
+const (
+	// LicenseKind is a LICENSE, LICENCE, UNLICENSE or COPYING file.
+	LicenseKind CandidateKind = iota
+	// NoticeKind is a NOTICE file.
+	NoticeKind
+	// ReadmeKind is a README file.
+	ReadmeKind
+)
+
+func (k CandidateKind) String() string {
+	switch k {
+	case LicenseKind:
+		return "license"
+	case NoticeKind:
+		return "notice"
+	case ReadmeKind:
+		return "readme"
+	}
+	return fmt.Sprintf("CandidateKind(%d)", int(k))
+}
+
+// KindOf returns the kind of the license file candidate at path.
+func KindOf(path string) CandidateKind {
+	switch name := filepath.Base(path); {
+	case readmeRegexp.MatchString(name):
+		return ReadmeKind
+	case noticeRegexp.MatchString(name):
+		return NoticeKind
+	}
+	return LicenseKind
+}
+
+// RankCandidates returns candidates, as returned by FindCandidates, sorted by
+// kind. Candidates of the same kind stay in their order, nearest first.
+func RankCandidates(candidates []string) []string {
+	ranked := slices.Clone(candidates)
+	slices.SortStableFunc(ranked, func(a, b string) int {
+		return int(KindOf(a)) - int(KindOf(b))
+	})
+	return ranked
+}
+
 func findAllUpwards(dir string, r *regexp.Regexp, stopAt string) ([]string, error) {
 	var foundPaths []string
 
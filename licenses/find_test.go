@@ -135,3 +135,44 @@ func TestFindCandidates(t *testing.T) {
 		})
 	}
 }
+
+func TestNoticeRegexp(t *testing.T) {
+	r := NoticeRegexp(DefaultNoticeNames...)
+	for name, want := range map[string]bool{
+		"NOTICE":                 true,
+		"notice.txt":             true,
+		"NOTICE.rst":             true,
+		"NOTICES":                true,
+		"AUTHORS":                true,
+		"CONTRIBUTORS.md":        true,
+		"THIRD_PARTY_NOTICES":    true,
+		"NOTICE.go":              false,
+		"LICENSE":                false,
+		"MY_AUTHORS":             false,
+		"THIRD_PARTY_NOTICES.md": true,
+	} {
+		if got := r.MatchString(name); got != want {
+			t.Errorf("NoticeRegexp(DefaultNoticeNames...).MatchString(%q) = %t, want %t", name, got, want)
+		}
+	}
+}
+
+func TestRankCandidates(t *testing.T) {
+	candidates := []string{
+		"/mod/pkg/README.md",
+		"/mod/pkg/NOTICE",
+		"/mod/pkg/COPYING",
+		"/mod/README",
+		"/mod/LICENSE",
+	}
+	want := []string{
+		"/mod/pkg/COPYING",
+		"/mod/LICENSE",
+		"/mod/pkg/NOTICE",
+		"/mod/pkg/README.md",
+		"/mod/README",
+	}
+	if diff := cmp.Diff(want, RankCandidates(candidates)); diff != "" {
+		t.Errorf("RankCandidates(%q) diff (-want +got): %s", candidates, diff)
+	}
+}
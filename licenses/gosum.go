@@ -0,0 +1,93 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// GoSum holds the module content hashes recorded in a go.sum file.
+// The hashes of go.mod files are not included.
+type GoSum map[string]string
+
+// ReadGoSum reads the go.sum file at path.
+func ReadGoSum(path string) (GoSum, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sum, err := ParseGoSum(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return sum, nil
+}
+
+// MainGoSum reads the go.sum file of the main module of the current directory.
+func MainGoSum(ctx context.Context) (GoSum, error) {
+	goMod, err := goEnv(ctx, "GOMOD")
+	if err != nil {
+		return nil, err
+	}
+	if goMod == "" || goMod == os.DevNull {
+		return nil, fmt.Errorf("cannot find go.sum: not in a Go module")
+	}
+	return ReadGoSum(filepath.Join(filepath.Dir(goMod), "go.sum"))
+}
+
+// ParseGoSum parses the content of a go.sum file.
+func ParseGoSum(data []byte) (GoSum, error) {
+	sum := GoSum{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: want 3 fields, got %d", lineNum, len(fields))
+		}
+		if strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+		sum[fields[0]+"@"+fields[1]] = fields[2]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return sum, nil
+}
+
+// Hash returns the hash recorded for the content of a module version.
+func (s GoSum) Hash(path, version string) (string, bool) {
+	hash, ok := s[path+"@"+version]
+	return hash, ok
+}
+
+// goEnv returns the value of a go environment variable, as reported by "go env".
+func goEnv(ctx context.Context, name string) (string, error) {
+	out, err := exec.CommandContext(ctx, "go", "env", name).Output()
+	if err != nil {
+		return "", fmt.Errorf("go env %s: %w", name, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,47 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseGoSum(t *testing.T) {
+	data := []byte(`github.com/spf13/cobra v1.7.0 h1:hyqWnYt1ZQShIddO5kBpj3vu05/++x6tJ6dg8EC572I=
+github.com/spf13/cobra v1.7.0/go.mod h1:uLxZILRyS/50WlhOIKD7W6V5bgeIt+4sICxh6uRMrb0=
+
+github.com/spf13/pflag v1.0.5 h1:iy+VFUOCP1a+8yFto/drg2CJ5u0yRoB7fZw3DKv/JXA=
+`)
+	got, err := ParseGoSum(data)
+	if err != nil {
+		t.Fatalf("ParseGoSum() = (_, %v), want (_, nil)", err)
+	}
+	want := GoSum{
+		"github.com/spf13/cobra@v1.7.0": "h1:hyqWnYt1ZQShIddO5kBpj3vu05/++x6tJ6dg8EC572I=",
+		"github.com/spf13/pflag@v1.0.5": "h1:iy+VFUOCP1a+8yFto/drg2CJ5u0yRoB7fZw3DKv/JXA=",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ParseGoSum() diff (-want +got): %s", diff)
+	}
+	if hash, ok := got.Hash("github.com/spf13/cobra", "v1.7.0"); !ok || hash != want["github.com/spf13/cobra@v1.7.0"] {
+		t.Errorf("Hash(cobra, v1.7.0) = (%q, %t), want (%q, true)", hash, ok, want["github.com/spf13/cobra@v1.7.0"])
+	}
+
+	if _, err := ParseGoSum([]byte("github.com/spf13/cobra v1.7.0\n")); err == nil {
+		t.Errorf("ParseGoSum() of a malformed line = (_, nil), want error")
+	}
+}
@@ -16,11 +16,17 @@ package licenses
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"go/build"
+	"io/fs"
+	"maps"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/google/go-licenses/v2/internal/third_party/pkgsite/source"
 	"golang.org/x/sync/errgroup"
@@ -39,6 +45,18 @@ type Library struct {
 	module *Module
 	// List of licenses for found at the LicenseFile.
 	Licenses []License
+	// Source files (Go, assembly, C, ...) and embedded files of the packages in this library.
+	sourceFiles []string
+	// Libraries imported by packages in this library.
+	imports []*Library
+	// Smallest number of module boundaries crossed by imports from the root packages.
+	depth int
+	// Library containing this one, for the assets of a library covered by another license.
+	parent *Library
+	// How LicenseFile was selected.
+	selection *LicenseSelection
+	// Zip file the module was read from, when it's not in the module cache.
+	moduleZip string
 }
 
 // PackagesError aggregates all Packages[].Errors into a single error.
@@ -61,7 +79,18 @@ func (e PackagesError) Error() string {
 // A library is a collection of one or more packages covered by the same license file.
 // Packages not covered by a license will be returned as individual libraries.
 // Standard library packages will be ignored.
+//
+// Libraries is equivalent to LoadLibraries with only IncludeTests and IgnoredPaths options set.
 func Libraries(ctx context.Context, classifier Classifier, includeTests bool, ignoredPaths []string, importPaths ...string) ([]*Library, error) {
+	return LoadLibraries(ctx, classifier, Options{
+		IncludeTests: includeTests,
+		IgnoredPaths: ignoredPaths,
+	}, importPaths...)
+}
+
+// LoadLibraries returns the collection of libraries used by this package, directly or transitively,
+// like Libraries does, with more options.
+func LoadLibraries(ctx context.Context, classifier Classifier, opts Options, importPaths ...string) ([]*Library, error) {
 	// These are the steps we take to find libraries:
 	// 1. we list all modules and all packages
 	// 2. for each package, we find a list of candidates
@@ -73,22 +102,39 @@ func Libraries(ctx context.Context, classifier Classifier, includeTests bool, ig
 	//    found licenses in that file, all the packages that had that file as
 	//    its first candidate and the module in which those packages live)
 
+	includeTests := opts.IncludeTests
+	ignoredPaths := opts.IgnoredPaths
+	logger := opts.logger()
+
 	cfg := &packages.Config{
-		Context: ctx,
-		Mode:    packages.NeedImports | packages.NeedDeps | packages.NeedFiles | packages.NeedName | packages.NeedModule,
-		Tests:   includeTests,
+		Context:    ctx,
+		Mode:       packages.NeedImports | packages.NeedDeps | packages.NeedFiles | packages.NeedEmbedFiles | packages.NeedName | packages.NeedModule,
+		Tests:      includeTests,
+		Dir:        opts.Dir,
+		Env:        opts.Env,
+		BuildFlags: opts.buildFlags(),
 	}
 
+	opts.progress(StageLoadPackages, 0, 1)
 	rootPkgs, err := packages.Load(cfg, importPaths...)
 	if err != nil {
 		return nil, err
 	}
+	opts.progress(StageLoadPackages, 1, 1)
 
 	vendoredSearch := []*Module{}
+	goSum := GoSum{}
 	for _, parentPkg := range rootPkgs {
 		if parentPkg.Module == nil {
 			continue
 		}
+		if parentPkg.Module.Main && parentPkg.Module.GoMod != "" {
+			sum, err := ReadGoSum(filepath.Join(filepath.Dir(parentPkg.Module.GoMod), "go.sum"))
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				logger.Warningf("Failed to read go.sum of module %s: %v", parentPkg.Module.Path, err)
+			}
+			maps.Copy(goSum, sum)
+		}
 
 		module := newModule(parentPkg.Module)
 		if module.Dir == "" {
@@ -108,6 +154,13 @@ func Libraries(ctx context.Context, classifier Classifier, includeTests bool, ig
 		pkgDir string
 		// moduleDir is the directory containing the module's source code.
 		moduleDir string
+		// files are the source files of the package.
+		files []string
+		// imports are the import paths of the non-standard packages imported by the package.
+		imports []string
+		// assets are the non-Go source files and embedded files of the package,
+		// when they are scanned for licenses.
+		assets []string
 	}
 
 	allModules := map[string]*Module{}
@@ -138,7 +191,7 @@ func Libraries(ctx context.Context, classifier Classifier, includeTests bool, ig
 			}
 
 			if len(p.OtherFiles) > 0 {
-				klog.Warningf("%q contains non-Go code that can't be inspected for further dependencies:\n%s", p.PkgPath, strings.Join(p.OtherFiles, "\n"))
+				logger.Warningf("%q contains non-Go code that can't be inspected for further dependencies:\n%s", p.PkgPath, strings.Join(p.OtherFiles, "\n"))
 			}
 
 			var pkgDir string
@@ -156,7 +209,7 @@ func Libraries(ctx context.Context, classifier Classifier, includeTests bool, ig
 
 			if p.Module == nil {
 				otherErrorOccurred = true
-				klog.Errorf("Package %s does not have module info. Non go modules projects are no longer supported. For feedback, refer to https://github.com/google/go-licenses/issues/128.", p.PkgPath)
+				logger.Errorf("Package %s does not have module info. Non go modules projects are no longer supported. For feedback, refer to https://github.com/google/go-licenses/issues/128.", p.PkgPath)
 				return false
 			}
 
@@ -166,7 +219,7 @@ func Libraries(ctx context.Context, classifier Classifier, includeTests bool, ig
 				// A known cause is that the module is vendored, so some information is lost.
 				isVendored := strings.Contains(pkgDir, "/vendor/")
 				if !isVendored {
-					klog.Warningf("module %s does not have dir and it's not vendored, cannot discover the license URL. Report to go-licenses developer if you see this.", module.Path)
+					logger.Warningf("module %s does not have dir and it's not vendored, cannot discover the license URL. Report to go-licenses developer if you see this.", module.Path)
 				} else {
 					// This is vendored. Handle this known special case.
 
@@ -190,17 +243,35 @@ func Libraries(ctx context.Context, classifier Classifier, includeTests bool, ig
 					}
 
 					if module.Dir == "" {
-						klog.Warningf("cannot find parent package of vendored module %s", module.Path)
+						logger.Warningf("cannot find parent package of vendored module %s", module.Path)
 					}
 				}
 			}
 
+			var imports []string
+			for _, imported := range p.Imports {
+				if !isStdLib(imported) {
+					imports = append(imports, imported.PkgPath)
+				}
+			}
+			// Embedded files are part of the binary, so they're source files too.
+			files := append(append(append([]string{}, p.GoFiles...), p.OtherFiles...), p.EmbedFiles...)
+			var assets []string
+			if opts.ScanAssets {
+				assets = append(append(assets, p.OtherFiles...), p.EmbedFiles...)
+			}
 			allPackages = append(allPackages, pkgInfo{
 				pkgPath:    p.PkgPath,
 				modulePath: module.Path,
 				pkgDir:     pkgDir,
 				moduleDir:  module.Dir,
+				files:      files,
+				imports:    imports,
+				assets:     assets,
 			})
+			if hash, ok := goSum.Hash(module.Path, module.fullVersion()); ok {
+				module.Sum = hash
+			}
 			allModules[module.Path] = module
 
 			return true
@@ -215,6 +286,18 @@ func Libraries(ctx context.Context, classifier Classifier, includeTests bool, ig
 		}
 	}
 
+	// Verify modules before their license files are trusted. Vendored modules
+	// have no directory of their own, so they can't be verified.
+	var dirModules []*Module
+	for _, m := range allModules {
+		if m.Dir != "" {
+			dirModules = append(dirModules, m)
+		}
+	}
+	if err := verifyModuleSums(ctx, opts, dirModules, goSum, verifyModuleDir); err != nil {
+		return nil, err
+	}
+
 	pkgCandidates := map[string][]string{}
 	allCandidates := map[string]struct{}{}
 	for _, pkg := range allPackages {
@@ -230,6 +313,12 @@ func Libraries(ctx context.Context, classifier Classifier, includeTests bool, ig
 	}
 
 	group, _ := errgroup.WithContext(ctx)
+	if opts.Concurrency > 0 {
+		group.SetLimit(opts.Concurrency)
+	}
+	var classifiedMu sync.Mutex
+	classified := 0
+	opts.progress(StageClassify, 0, len(allCandidates))
 	foundLicenseSlice := make([]struct {
 		candidate string
 		licenes   []License
@@ -242,8 +331,12 @@ func Libraries(ctx context.Context, classifier Classifier, includeTests bool, ig
 
 		group.Go(func() error {
 			licenses, err := classifier.Identify(candidate)
+			classifiedMu.Lock()
+			classified++
+			opts.progress(StageClassify, classified, len(allCandidates))
+			classifiedMu.Unlock()
 			if err != nil {
-				klog.Errorf("Failed to parse %s: %v", candidate, err)
+				logger.Errorf("Failed to parse %s: %v", candidate, err)
 				return nil // Continue even if one LICENSE file fails to parse.
 			}
 
@@ -272,29 +365,35 @@ func Libraries(ctx context.Context, classifier Classifier, includeTests bool, ig
 	}
 
 	pkgsByLicense := make(map[string][]pkgInfo)
+	pkgSelections := make(map[string]*LicenseSelection)
 	for _, pkg := range allPackages {
-		candidates := pkgCandidates[pkg.pkgDir]
+		// Candidates are ranked, so that a README mentioning a license doesn't
+		// win over the actual license file further up.
+		selection := newLicenseSelection(pkg.pkgDir, pkgCandidates[pkg.pkgDir], foundLicenses)
 
 		bestCandidate := ""
-		for _, candidate := range candidates {
-			if _, ok := foundLicenses[candidate]; ok {
-				bestCandidate = candidate
-				break
-			}
+		if selected := selection.Selected(); selected != nil {
+			bestCandidate = selected.Path
+			pkgSelections[pkg.pkgDir] = selection
 		}
 
 		pkgsByLicense[bestCandidate] = append(pkgsByLicense[bestCandidate], pkg)
 	}
 
+	depths := importDepths(rootPkgs)
 	var libraries []*Library
+	libraryOf := map[string]*Library{}
 	for licenseFile, pkgs := range pkgsByLicense {
 		if licenseFile == "" {
 			// No license for these packages - return each one as a separate library.
 			for _, p := range pkgs {
-				libraries = append(libraries, &Library{
-					Packages: []string{p.pkgPath},
-					module:   allModules[p.modulePath],
-				})
+				lib := &Library{
+					Packages:    []string{p.pkgPath},
+					module:      allModules[p.modulePath],
+					sourceFiles: p.files,
+				}
+				libraryOf[p.pkgPath] = lib
+				libraries = append(libraries, lib)
 			}
 			continue
 		}
@@ -304,10 +403,34 @@ func Libraries(ctx context.Context, classifier Classifier, includeTests bool, ig
 			Licenses:    foundLicenses[licenseFile],
 			Packages:    make([]string, len(pkgs)),
 			module:      allModules[pkgs[0].modulePath],
+			selection:   pkgSelections[pkgs[0].pkgDir],
 		}
 
+		// The packages of the library may be in different directories, so their
+		// candidates may disagree differently. Each disagreement is reported, and
+		// the selection of the first package with one is kept on the library.
+		var conflicts []string
 		for i, p := range pkgs {
 			lib.Packages[i] = p.pkgPath
+			lib.sourceFiles = append(lib.sourceFiles, p.files...)
+			libraryOf[p.pkgPath] = lib
+			selection := pkgSelections[p.pkgDir]
+			if len(selection.Conflicts()) == 0 || slices.Contains(conflicts, selection.String()) {
+				continue
+			}
+			if len(conflicts) == 0 {
+				lib.selection = selection
+			}
+			conflicts = append(conflicts, selection.String())
+		}
+		sort.Strings(lib.sourceFiles)
+		lib.sourceFiles = slices.Compact(lib.sourceFiles)
+
+		if lib.selection.Selected().Kind == ReadmeKind {
+			logger.Warningf("License of library %s is taken from README file %s, which may only mention a license. Please verify!", lib.Name(), licenseFile)
+		}
+		for _, conflict := range conflicts {
+			logger.Warningf("License file candidates of library %s disagree, %s. Please verify!", lib.Name(), conflict)
 		}
 
 		libraries = append(libraries, lib)
@@ -318,9 +441,85 @@ func Libraries(ctx context.Context, classifier Classifier, includeTests bool, ig
 		return libraries[i].Name() < libraries[j].Name()
 	})
 
+	// Turn the package imports into imports between libraries. Imports of
+	// ignored packages are dropped.
+	for _, pkg := range allPackages {
+		from := libraryOf[pkg.pkgPath]
+		for _, imported := range pkg.imports {
+			if to, ok := libraryOf[imported]; ok && to != from && !slices.Contains(from.imports, to) {
+				from.imports = append(from.imports, to)
+			}
+		}
+	}
+	for _, lib := range libraries {
+		sort.Slice(lib.imports, func(i, j int) bool {
+			return lib.imports[i].Name() < lib.imports[j].Name()
+		})
+		lib.depth = -1
+		for _, pkg := range lib.Packages {
+			if depth, ok := depths[pkg]; ok && (lib.depth < 0 || depth < lib.depth) {
+				lib.depth = depth
+			}
+		}
+	}
+
+	if opts.ScanAssets {
+		usedLicenseFiles := map[string]bool{}
+		for _, lib := range libraries {
+			usedLicenseFiles[lib.LicenseFile] = true
+		}
+		assetPkgs := map[*Library][]assetPackage{}
+		for _, pkg := range allPackages {
+			lib := libraryOf[pkg.pkgPath]
+			assetPkgs[lib] = append(assetPkgs[lib], assetPackage{pkgPath: pkg.pkgPath, pkgDir: pkg.pkgDir, assets: pkg.assets})
+		}
+		for _, lib := range slices.Clone(libraries) {
+			libraries = append(libraries, findAssetLibraries(classifier, logger, lib, assetPkgs[lib], usedLicenseFiles)...)
+		}
+		sort.Slice(libraries, func(i, j int) bool {
+			return libraries[i].Name() < libraries[j].Name()
+		})
+	}
+
 	return libraries, nil
 }
 
+// importDepths returns the smallest number of module boundaries crossed by
+// imports from roots to each non-standard package, by package path. Ignored
+// packages are followed like any other, so that ignoring the main module
+// doesn't turn its direct dependencies into indirect ones.
+func importDepths(roots []*packages.Package) map[string]int {
+	// This is a breadth-first search where imports within a module cost 0
+	// and imports of another module cost 1.
+	seen := map[*packages.Package]bool{}
+	depths := map[string]int{}
+	frontier := append([]*packages.Package{}, roots...)
+	for depth := 0; len(frontier) > 0; depth++ {
+		var next []*packages.Package
+		for len(frontier) > 0 {
+			p := frontier[len(frontier)-1]
+			frontier = frontier[:len(frontier)-1]
+			if seen[p] || isStdLib(p) {
+				continue
+			}
+			seen[p] = true
+			if d, ok := depths[p.PkgPath]; !ok || depth < d {
+				depths[p.PkgPath] = depth
+			}
+			for _, imported := range p.Imports {
+				// A test binary belongs to no module, but only imports the root packages under test.
+				if isTestBinary(p) || (p.Module != nil && imported.Module != nil && p.Module.Path == imported.Module.Path) {
+					frontier = append(frontier, imported)
+				} else {
+					next = append(next, imported)
+				}
+			}
+		}
+		frontier = next
+	}
+	return depths
+}
+
 // Name is the common prefix of the import paths for all of the packages in this library.
 func (l *Library) Name() string {
 	return commonAncestor(l.Packages)
@@ -402,6 +601,119 @@ func (l *Library) FileURL(ctx context.Context, cl *source.Client, filePath strin
 	return remote.FileURL(relativePath), nil
 }
 
+// Notices returns the paths of copyright notice files that apply to this library:
+// files whose name matches r, in the directories of its packages and their parent
+// directories up to the module root, like license candidates are found by
+// FindCandidates. Files closer to the packages come first.
+func (l *Library) Notices(r *regexp.Regexp) ([]string, error) {
+	if l.LicenseFile == "" {
+		return nil, nil
+	}
+	licenseDir := filepath.Dir(l.LicenseFile)
+	rootDir := licenseDir
+	if l.module != nil && l.module.Dir != "" && strings.HasPrefix(licenseDir, l.module.Dir) {
+		rootDir = l.module.Dir
+	}
+	dirs := []string{licenseDir}
+	for _, f := range l.sourceFiles {
+		if dir := filepath.Dir(f); strings.HasPrefix(dir, rootDir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	// Sort deepest directories first, so that closer notices come first.
+	sort.Slice(dirs, func(i, j int) bool {
+		if len(dirs[i]) != len(dirs[j]) {
+			return len(dirs[i]) > len(dirs[j])
+		}
+		return dirs[i] < dirs[j]
+	})
+
+	var notices []string
+	seen := map[string]bool{}
+	for _, dir := range slices.Compact(dirs) {
+		found, err := findAllUpwards(dir, r, rootDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, notice := range found {
+			if !seen[notice] {
+				seen[notice] = true
+				notices = append(notices, notice)
+			}
+		}
+	}
+	return notices, nil
+}
+
+// SourceFiles returns the paths of the source files (Go, assembly, C, ...) of
+// the packages in this library, as seen by the build, and the files they embed
+// with //go:embed. Test files are included only when tests were included when
+// loading the library.
+func (l *Library) SourceFiles() []string {
+	return l.sourceFiles
+}
+
+// Imports returns the libraries imported by packages in this library, sorted
+// by name. Libraries only imported through ignored packages are not included.
+func (l *Library) Imports() []*Library {
+	return l.imports
+}
+
+// Depth returns the smallest number of module boundaries crossed by imports
+// from the root packages to this library: 0 when the library is in the module
+// of a root package, 1 when it's a direct dependency of such a module and more
+// when it's only an indirect dependency. It returns -1 when the depth is unknown.
+func (l *Library) Depth() int {
+	return l.depth
+}
+
+// IsDirect returns true if the library is a direct dependency of the modules
+// of the root packages.
+func (l *Library) IsDirect() bool {
+	return l.depth == 1
+}
+
+// Parent returns the library containing this one, when this library is made of
+// assets of the parent's packages, like C sources, embedded files or bundled
+// third-party code, covered by their own license. Its Packages are then not
+// actual packages, but the paths of the assets relative to the packages.
+// Parent returns nil for other libraries.
+func (l *Library) Parent() *Library {
+	return l.parent
+}
+
+// LicenseSelection explains how LicenseFile was selected for a package of the
+// library. When the candidates of several packages disagree, only the selection
+// of the first of them is returned, the others are only logged as warnings. It
+// returns nil if the library has no license file or is made of assets of another
+// library.
+func (l *Library) LicenseSelection() *LicenseSelection {
+	return l.selection
+}
+
+// Module returns the module containing the library, or nil if it's not in a module.
+// The returned Module is a copy, so changing it doesn't affect the library.
+func (l *Library) Module() *Module {
+	if l.module == nil {
+		return nil
+	}
+	m := *l.module
+	if m.Original != nil {
+		original := *m.Original
+		m.Original = &original
+	}
+	return &m
+}
+
+// ModulePath returns the path of the library's module, or an empty string if
+// it's not in a module.
+func (l *Library) ModulePath() string {
+	if l.module != nil {
+		return l.module.Path
+	}
+	return ""
+}
+
 func (l *Library) Version() string {
 	if l.module != nil {
 		return l.module.Version
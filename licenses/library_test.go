@@ -16,12 +16,18 @@ package licenses
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/google/go-licenses/v2/internal/third_party/pkgsite/source"
+	"golang.org/x/tools/go/packages"
 )
 
 func TestLibraries(t *testing.T) {
@@ -169,6 +175,295 @@ func TestLibraries(t *testing.T) {
 	}
 }
 
+func TestLibrariesSourceFiles(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Cannot get working directory: %v", err)
+	}
+	classifier := classifierStub{
+		licenses: map[string][]License{
+			"testdata/direct/LICENSE":   {{Name: "foo", Type: Notice}},
+			"testdata/indirect/LICENSE": {{Name: "foo", Type: Notice}},
+		},
+	}
+	importPath := "github.com/google/go-licenses/v2/licenses/testdata/direct"
+	gotLibs, err := Libraries(context.Background(), classifier, false, nil, importPath)
+	if err != nil {
+		t.Fatalf("Libraries(_, %q) = (_, %q), want (_, nil)", importPath, err)
+	}
+	wantFiles := map[string][]string{
+		"github.com/google/go-licenses/v2/licenses/testdata/direct": {
+			wd + "/testdata/direct/direct.go",
+			wd + "/testdata/direct/subpkg/subpkg.go",
+		},
+		"github.com/google/go-licenses/v2/licenses/testdata/indirect": {
+			wd + "/testdata/indirect/indirect.go",
+		},
+	}
+	for _, lib := range gotLibs {
+		if diff := cmp.Diff(wantFiles[lib.Name()], lib.SourceFiles()); diff != "" {
+			t.Errorf("Libraries(_, %q) %s SourceFiles() diff (-want +got): %s", importPath, lib.Name(), diff)
+		}
+	}
+}
+
+func TestLibrariesImports(t *testing.T) {
+	classifier := classifierStub{
+		licenses: map[string][]License{
+			"testdata/LICENSE":          {{Name: "foo", Type: Notice}},
+			"testdata/direct/LICENSE":   {{Name: "foo", Type: Notice}},
+			"testdata/indirect/LICENSE": {{Name: "foo", Type: Notice}},
+		},
+	}
+	importPath := "github.com/google/go-licenses/v2/licenses/testdata"
+	gotLibs, err := Libraries(context.Background(), classifier, false, nil, importPath)
+	if err != nil {
+		t.Fatalf("Libraries(_, %q) = (_, %q), want (_, nil)", importPath, err)
+	}
+	wantImports := map[string][]string{
+		"github.com/google/go-licenses/v2/licenses/testdata":        {"github.com/google/go-licenses/v2/licenses/testdata/direct"},
+		"github.com/google/go-licenses/v2/licenses/testdata/direct": {"github.com/google/go-licenses/v2/licenses/testdata/indirect"},
+	}
+	for _, lib := range gotLibs {
+		var gotImports []string
+		for _, imported := range lib.Imports() {
+			gotImports = append(gotImports, imported.Name())
+		}
+		if diff := cmp.Diff(wantImports[lib.Name()], gotImports); diff != "" {
+			t.Errorf("Libraries(_, %q) %s Imports() diff (-want +got): %s", importPath, lib.Name(), diff)
+		}
+	}
+}
+
+func TestImportDepths(t *testing.T) {
+	mainModule := &packages.Module{Path: "example.com/app", Main: true}
+	newPkg := func(path string, module *packages.Module, imports ...*packages.Package) *packages.Package {
+		pkg := &packages.Package{PkgPath: path, Module: module, Imports: map[string]*packages.Package{}}
+		for _, imported := range imports {
+			pkg.Imports[imported.PkgPath] = imported
+		}
+		return pkg
+	}
+	c := newPkg("example.com/c", &packages.Module{Path: "example.com/c"})
+	b := newPkg("example.com/b/sub", &packages.Module{Path: "example.com/b"}, c)
+	a := newPkg("example.com/a", &packages.Module{Path: "example.com/a"}, b)
+	internal := newPkg("example.com/app/internal", mainModule, a)
+	app := newPkg("example.com/app", mainModule, internal, c)
+	appTest := newPkg("example.com/app.test", nil, app)
+
+	got := importDepths([]*packages.Package{appTest})
+	want := map[string]int{
+		"example.com/app.test":     0,
+		"example.com/app":          0,
+		"example.com/app/internal": 0,
+		"example.com/a":            1,
+		"example.com/c":            1,
+		"example.com/b/sub":        2,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("importDepths() diff (-want +got): %s", diff)
+	}
+}
+
+func TestLibrariesLicenseSelection(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Cannot get working directory: %v", err)
+	}
+	importPath := "github.com/google/go-licenses/v2/licenses/testdata/readme"
+	readme := wd + "/testdata/readme/README.md"
+	license := wd + "/testdata/LICENSE"
+	for _, test := range []struct {
+		desc           string
+		licenses       map[string][]License
+		wantCandidates []LicenseCandidate
+		wantWarning    string
+	}{
+		{
+			desc: "License file wins over a nearer README",
+			licenses: map[string][]License{
+				"testdata/LICENSE":          {{Name: "foo", Type: Notice}},
+				"testdata/readme/README.md": {{Name: "bar", Type: Notice}},
+			},
+			wantCandidates: []LicenseCandidate{
+				{Path: license, Kind: LicenseKind, Licenses: []License{{Name: "foo", Type: Notice}}, Distance: 1},
+				{Path: readme, Kind: ReadmeKind, Licenses: []License{{Name: "bar", Type: Notice}}, Distance: 0},
+			},
+			wantWarning: "W License file candidates of library " + importPath + " disagree, selected " + license + " (license: foo) over " + readme + " (readme: bar). Please verify!",
+		},
+		{
+			desc: "README is the only identified candidate",
+			licenses: map[string][]License{
+				"testdata/readme/README.md": {{Name: "bar", Type: Notice}},
+			},
+			wantCandidates: []LicenseCandidate{
+				{Path: readme, Kind: ReadmeKind, Licenses: []License{{Name: "bar", Type: Notice}}, Distance: 0},
+			},
+			wantWarning: "W License of library " + importPath + " is taken from README file " + readme + ", which may only mention a license. Please verify!",
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			logger := &recordingLogger{}
+			gotLibs, err := LoadLibraries(context.Background(), classifierStub{licenses: test.licenses}, Options{Logger: logger}, importPath)
+			if err != nil {
+				t.Fatalf("LoadLibraries(_, %q) = (_, %q), want (_, nil)", importPath, err)
+			}
+			if len(gotLibs) != 1 {
+				t.Fatalf("len(LoadLibraries(_, %q)) = %d, want 1", importPath, len(gotLibs))
+			}
+			selection := gotLibs[0].LicenseSelection()
+			if diff := cmp.Diff(test.wantCandidates, selection.Candidates); diff != "" {
+				t.Errorf("LicenseSelection().Candidates diff (-want +got): %s", diff)
+			}
+			if gotLibs[0].LicenseFile != test.wantCandidates[0].Path {
+				t.Errorf("LicenseFile = %q, want %q", gotLibs[0].LicenseFile, test.wantCandidates[0].Path)
+			}
+			if !slices.Contains(logger.messages, test.wantWarning) {
+				t.Errorf("LoadLibraries() logged %q, want it to contain %q", logger.messages, test.wantWarning)
+			}
+		})
+	}
+}
+
+type recordingLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) Warningf(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, "W "+fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Errorf(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, "E "+fmt.Sprintf(format, args...))
+}
+
+func TestLoadLibraries(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Cannot get working directory: %v", err)
+	}
+	classifier := classifierStub{
+		licenses: map[string][]License{
+			"testdata/LICENSE": {{Name: "foo", Type: Notice}},
+		},
+	}
+	logger := &recordingLogger{}
+	var progress []Progress
+	opts := Options{
+		Dir:         wd + "/testdata/tags",
+		Tags:        []string{"tags"},
+		Concurrency: 1,
+		Logger:      logger,
+		Progress:    func(p Progress) { progress = append(progress, p) },
+	}
+	gotLibs, err := LoadLibraries(context.Background(), classifier, opts, ".")
+	if err != nil {
+		t.Fatalf("LoadLibraries(_, %+v, \".\") = (_, %q), want (_, nil)", opts, err)
+	}
+
+	// The tagged package and its dependency are covered by testdata/LICENSE
+	// because the classifier fails to parse their own LICENSE files.
+	if len(gotLibs) != 1 {
+		t.Fatalf("len(LoadLibraries()) = %d, want 1", len(gotLibs))
+	}
+	wantPackages := []string{
+		"github.com/google/go-licenses/v2/licenses/testdata/tags",
+		"github.com/google/go-licenses/v2/licenses/testdata/indirect",
+	}
+	if diff := cmp.Diff(wantPackages, gotLibs[0].Packages); diff != "" {
+		t.Errorf("LoadLibraries()[0].Packages diff (-want +got): %s", diff)
+	}
+	wantMessage := "E Failed to parse " + wd + "/testdata/indirect/LICENSE: classifierStub has no programmed response for \"testdata/indirect/LICENSE\""
+	if !slices.Contains(logger.messages, wantMessage) {
+		t.Errorf("LoadLibraries() logged %q, want it to contain %q", logger.messages, wantMessage)
+	}
+
+	if len(progress) < 3 {
+		t.Fatalf("LoadLibraries() reported progress %v, want at least 3 updates", progress)
+	}
+	wantLoad := []Progress{
+		{Stage: StageLoadPackages, Done: 0, Total: 1},
+		{Stage: StageLoadPackages, Done: 1, Total: 1},
+	}
+	if diff := cmp.Diff(wantLoad, progress[:2]); diff != "" {
+		t.Errorf("LoadLibraries() progress diff (-want +got): %s", diff)
+	}
+	for i, p := range progress[2:] {
+		if p.Stage != StageClassify || p.Done != i || p.Total != len(progress)-3 {
+			t.Errorf("LoadLibraries() progress[%d] = %v, want %v", i+2, p, Progress{Stage: StageClassify, Done: i, Total: len(progress) - 3})
+		}
+	}
+}
+
+func TestLibraryModule(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Cannot get working directory: %v", err)
+	}
+	classifier := classifierStub{
+		licenses: map[string][]License{
+			"testdata/indirect/LICENSE": {{Name: "foo", Type: Notice}},
+		},
+	}
+	importPath := "github.com/google/go-licenses/v2/licenses/testdata/indirect"
+	gotLibs, err := Libraries(context.Background(), classifier, false, nil, importPath)
+	if err != nil {
+		t.Fatalf("Libraries(_, %q) = (_, %q), want (_, nil)", importPath, err)
+	}
+	if len(gotLibs) != 1 {
+		t.Fatalf("len(Libraries(_, %q)) = %d, want 1", importPath, len(gotLibs))
+	}
+	want := &Module{
+		Path:      "github.com/google/go-licenses/v2",
+		Dir:       filepath.Dir(wd),
+		Main:      true,
+		GoVersion: "1.23.0",
+	}
+	got := gotLibs[0].Module()
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(Module{})); diff != "" {
+		t.Errorf("Libraries(_, %q)[0].Module() diff (-want +got): %s", importPath, diff)
+	}
+	got.Path = "changed"
+	if gotLibs[0].ModulePath() == "changed" {
+		t.Errorf("Changing Module() changed the library's module")
+	}
+
+	replaced := &Library{module: &Module{Path: "example.com/fork", Original: &Module{Path: "example.com/upstream"}}}
+	replaced.Module().Original.Path = "changed"
+	if replaced.module.Original.Path == "changed" {
+		t.Errorf("Changing Module().Original changed the library's module")
+	}
+}
+
+func TestLibraryNotices(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Cannot get working directory: %v", err)
+	}
+	lib := &Library{
+		LicenseFile: wd + "/testdata/LICENSE",
+		Packages:    []string{"github.com/google/go-licenses/v2/licenses/testdata/notice"},
+		module: &Module{
+			Path: "github.com/google/go-licenses/v2/licenses/testdata",
+			Dir:  wd + "/testdata",
+		},
+		sourceFiles: []string{wd + "/testdata/notice/notice.go"},
+	}
+	got, err := lib.Notices(NoticeRegexp(DefaultNoticeNames...))
+	if err != nil {
+		t.Fatalf("Notices() = (_, %v), want (_, nil)", err)
+	}
+	want := []string{wd + "/testdata/notice/NOTICE.txt"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Notices() diff (-want +got): %s", diff)
+	}
+}
+
 func TestLibraryName(t *testing.T) {
 	for _, test := range []struct {
 		desc     string
@@ -0,0 +1,98 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+// ModuleZip returns the path of the zip file of the library's module in the
+// module cache (GOMODCACHE), or in the proxy directory for libraries returned by
+// ProxyLibraries, after checking that its content matches the hash recorded in
+// sum. The verified hash is returned too.
+//
+// Modules without a zip file, like the main module or modules replaced by a
+// local directory, result in an error wrapping fs.ErrNotExist.
+func (l *Library) ModuleZip(ctx context.Context, sum GoSum) (zipPath, hash string, err error) {
+	if l == nil {
+		return "", "", fmt.Errorf("library is nil")
+	}
+	wrap := func(err error) error {
+		return fmt.Errorf("getting module zip in library %s: %w", l.Name(), err)
+	}
+	m := l.module
+	if m == nil || m.Path == "" {
+		return "", "", wrap(fmt.Errorf("empty go module info: %w", fs.ErrNotExist))
+	}
+	version := m.fullVersion()
+	if version == "" {
+		return "", "", wrap(fmt.Errorf("module %s has empty version: %w", m.Path, fs.ErrNotExist))
+	}
+	zipPath = l.moduleZip
+	if zipPath == "" {
+		modCache, err := goEnv(ctx, "GOMODCACHE")
+		if err != nil {
+			return "", "", wrap(err)
+		}
+		if zipPath, err = moduleZipPath(modCache, m.Path, version); err != nil {
+			return "", "", wrap(err)
+		}
+	}
+	if _, err := os.Stat(zipPath); err != nil {
+		return "", "", wrap(err)
+	}
+	if hash, err = verifyModuleZip(zipPath, m.Path, version, sum); err != nil {
+		return "", "", wrap(err)
+	}
+	return zipPath, hash, nil
+}
+
+// moduleZipPath returns the path of the zip file of a module version in the
+// module cache at modCache.
+func moduleZipPath(modCache, modulePath, version string) (string, error) {
+	escapedPath, err := module.EscapePath(modulePath)
+	if err != nil {
+		return "", err
+	}
+	escapedVersion, err := module.EscapeVersion(version)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(modCache, "cache", "download", filepath.FromSlash(escapedPath), "@v", escapedVersion+".zip"), nil
+}
+
+// verifyModuleZip checks that the content of the module zip at zipPath matches
+// the hash recorded for the module version in sum, and returns the hash.
+func verifyModuleZip(zipPath, modulePath, version string, sum GoSum) (string, error) {
+	want, ok := sum.Hash(modulePath, version)
+	if !ok {
+		return "", fmt.Errorf("go.sum has no hash for %s@%s", modulePath, version)
+	}
+	got, err := dirhash.HashZip(zipPath, dirhash.Hash1)
+	if err != nil {
+		return "", err
+	}
+	if got != want {
+		return "", fmt.Errorf("%s has hash %s, but go.sum has %s for %s@%s", zipPath, got, want, modulePath, version)
+	}
+	return got, nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+func TestModuleZipPath(t *testing.T) {
+	got, err := moduleZipPath("/go/pkg/mod", "github.com/BurntSushi/toml", "v2.0.0+incompatible")
+	if err != nil {
+		t.Fatalf("moduleZipPath() = (_, %v), want (_, nil)", err)
+	}
+	want := filepath.FromSlash("/go/pkg/mod/cache/download/github.com/!burnt!sushi/toml/@v/v2.0.0+incompatible.zip")
+	if got != want {
+		t.Errorf("moduleZipPath() = %q, want %q", got, want)
+	}
+}
+
+func TestVerifyModuleZip(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "v1.0.0.zip")
+	writeTestZip(t, zipPath, map[string]string{
+		"example.com/mod@v1.0.0/LICENSE": "license text",
+		"example.com/mod@v1.0.0/mod.go":  "package mod",
+	})
+	hash, err := dirhash.HashZip(zipPath, dirhash.Hash1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		desc    string
+		sum     GoSum
+		wantErr bool
+	}{
+		{
+			desc: "Matching hash",
+			sum:  GoSum{"example.com/mod@v1.0.0": hash},
+		},
+		{
+			desc:    "Mismatching hash",
+			sum:     GoSum{"example.com/mod@v1.0.0": "h1:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="},
+			wantErr: true,
+		},
+		{
+			desc:    "Missing hash",
+			sum:     GoSum{},
+			wantErr: true,
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			got, err := verifyModuleZip(zipPath, "example.com/mod", "v1.0.0", test.sum)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("verifyModuleZip() = (_, %v), want err? %t", err, test.wantErr)
+			} else if gotErr {
+				return
+			}
+			if got != hash {
+				t.Errorf("verifyModuleZip() = %q, want %q", got, hash)
+			}
+		})
+	}
+}
+
+func writeTestZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
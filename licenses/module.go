@@ -23,13 +23,25 @@ import (
 // Module provides module information for a package.
 type Module struct {
 	// Differences from packages.Module:
-	// * Replace field is removed, it's only an implementation detail in this package.
-	//   If a module is replaced, we'll directly return the replaced module.
+	// * If a module is replaced, Path, Version and Dir are those of the replacement,
+	//   and Original holds the module that was replaced.
 	// * Version field +incompatible suffix is trimmed.
-	// * Main, ModuleError, Time, Indirect, GoMod, GoVersion fields are removed, because they are not used.
-	Path    string // module path
-	Version string // module version
-	Dir     string // directory holding files for this module, if any
+	// * ModuleError, Time, GoMod fields are removed, because they are not used.
+	Path      string // module path
+	Version   string // module version
+	Dir       string // directory holding files for this module, if any
+	Main      bool   // is this the main module?
+	Indirect  bool   // is this module only an indirect dependency of main module?
+	GoVersion string // go version used in module
+	Sum       string // hash of the module content recorded in the main module's go.sum, if any
+
+	// Original is the module as required by the main module, before a replace
+	// directive was applied. It is nil when the module is not replaced.
+	Original *Module
+
+	// rawVersion is the module version as reported by go, including any
+	// +incompatible suffix. It's needed to find the module in the module cache.
+	rawVersion string
 }
 
 func newModule(mod *packages.Module) *Module {
@@ -52,16 +64,35 @@ func newModule(mod *packages.Module) *Module {
 	// Haven't confirmed, but we may also need to override the
 	// entire struct when using replace directive with local folders.
 	tmp := *mod
+	var original *Module
 	if tmp.Replace != nil {
+		original = &Module{
+			Path:       mod.Path,
+			Version:    strings.TrimSuffix(mod.Version, "+incompatible"),
+			rawVersion: mod.Version,
+		}
 		tmp = *tmp.Replace
 	}
 
 	// The +incompatible suffix does not affect module version.
 	// ref: https://golang.org/ref/mod#incompatible-versions
-	tmp.Version = strings.TrimSuffix(tmp.Version, "+incompatible")
 	return &Module{
-		Path:    tmp.Path,
-		Version: tmp.Version,
-		Dir:     tmp.Dir,
+		Path:       tmp.Path,
+		Version:    strings.TrimSuffix(tmp.Version, "+incompatible"),
+		Dir:        tmp.Dir,
+		Main:       mod.Main,
+		Indirect:   mod.Indirect,
+		GoVersion:  tmp.GoVersion,
+		Original:   original,
+		rawVersion: tmp.Version,
+	}
+}
+
+// fullVersion returns the module version including any +incompatible suffix,
+// as used by the module cache and module proxies.
+func (m *Module) fullVersion() string {
+	if m.rawVersion != "" {
+		return m.rawVersion
 	}
+	return m.Version
 }
@@ -0,0 +1,98 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestNewModule(t *testing.T) {
+	for _, test := range []struct {
+		desc string
+		mod  packages.Module
+		want *Module
+	}{
+		{
+			desc: "Not replaced",
+			mod: packages.Module{
+				Path:      "github.com/pkg/errors",
+				Version:   "v0.9.1",
+				Dir:       "/mod/github.com/pkg/errors@v0.9.1",
+				Indirect:  true,
+				GoVersion: "1.12",
+			},
+			want: &Module{
+				Path:      "github.com/pkg/errors",
+				Version:   "v0.9.1",
+				Dir:       "/mod/github.com/pkg/errors@v0.9.1",
+				Indirect:  true,
+				GoVersion: "1.12",
+			},
+		},
+		{
+			desc: "Replaced by a fork",
+			mod: packages.Module{
+				Path:    "github.com/docker/docker",
+				Version: "v20.10.0+incompatible",
+				Replace: &packages.Module{
+					Path:      "github.com/fork/docker",
+					Version:   "v20.10.1+incompatible",
+					Dir:       "/mod/github.com/fork/docker@v20.10.1+incompatible",
+					GoVersion: "1.13",
+				},
+			},
+			want: &Module{
+				Path:      "github.com/fork/docker",
+				Version:   "v20.10.1",
+				Dir:       "/mod/github.com/fork/docker@v20.10.1+incompatible",
+				GoVersion: "1.13",
+				Original: &Module{
+					Path:    "github.com/docker/docker",
+					Version: "v20.10.0",
+				},
+			},
+		},
+		{
+			desc: "Replaced by a local directory",
+			mod: packages.Module{
+				Path:    "github.com/pkg/errors",
+				Version: "v0.9.1",
+				Replace: &packages.Module{
+					Path: "../errors",
+					Dir:  "/src/errors",
+				},
+			},
+			want: &Module{
+				Path: "../errors",
+				Dir:  "/src/errors",
+				Original: &Module{
+					Path:    "github.com/pkg/errors",
+					Version: "v0.9.1",
+				},
+			},
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			got := newModule(&test.mod)
+			if diff := cmp.Diff(test.want, got, cmpopts.IgnoreUnexported(Module{})); diff != "" {
+				t.Errorf("newModule(%+v) diff (-want +got): %s", test.mod, diff)
+			}
+		})
+	}
+}
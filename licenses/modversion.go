@@ -0,0 +1,203 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/module"
+)
+
+// ModuleVersionLicense is the license at the root of a module version.
+type ModuleVersionLicense struct {
+	Path    string
+	Version string
+	// LicenseFile is the path of the license file, relative to the module root.
+	// It's empty when no license was identified.
+	LicenseFile string
+	Licenses    []License
+}
+
+// IdentifyModuleVersion identifies the license at the root of a module version,
+// without loading its packages. The module is read from the zip file for the
+// version in proxyDir, a directory laid out like a GOPROXY, when proxyDir is not
+// empty. Otherwise, it's read from the module cache (GOMODCACHE), extracted or as
+// a zip file. License files that fail to be classified are reported to
+// opts.Logger, other options are not used.
+//
+// When the module version can't be found, the error wraps fs.ErrNotExist.
+func IdentifyModuleVersion(ctx context.Context, classifier Classifier, opts Options, proxyDir, modulePath, version string) (*ModuleVersionLicense, error) {
+	logger := opts.logger()
+	wrap := func(err error) error {
+		return fmt.Errorf("identifying license of %s@%s: %w", modulePath, version, err)
+	}
+	if proxyDir != "" {
+		zipPath, err := proxyZipPath(proxyDir, modulePath, version)
+		if err != nil {
+			return nil, wrap(err)
+		}
+		lic, err := identifyModuleZip(classifier, logger, zipPath, modulePath, version)
+		if err != nil {
+			return nil, wrap(err)
+		}
+		return lic, nil
+	}
+
+	modCache, err := goEnv(ctx, "GOMODCACHE")
+	if err != nil {
+		return nil, wrap(err)
+	}
+	dir, err := moduleDirPath(modCache, modulePath, version)
+	if err != nil {
+		return nil, wrap(err)
+	}
+	if _, err := os.Stat(dir); err == nil {
+		lic, err := identifyModuleDir(classifier, logger, dir, modulePath, version)
+		if err != nil {
+			return nil, wrap(err)
+		}
+		return lic, nil
+	}
+	zipPath, err := moduleZipPath(modCache, modulePath, version)
+	if err != nil {
+		return nil, wrap(err)
+	}
+	lic, err := identifyModuleZip(classifier, logger, zipPath, modulePath, version)
+	if err != nil {
+		return nil, wrap(fmt.Errorf("%w, run \"go mod download %s@%s\"", err, modulePath, version))
+	}
+	return lic, nil
+}
+
+// proxyZipPath returns the path of the zip file of a module version in proxyDir,
+// a directory laid out like a GOPROXY. proxyDir may be a file:// URL.
+func proxyZipPath(proxyDir, modulePath, version string) (string, error) {
+	escapedPath, err := module.EscapePath(modulePath)
+	if err != nil {
+		return "", err
+	}
+	escapedVersion, err := module.EscapeVersion(version)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.FromSlash(strings.TrimPrefix(proxyDir, "file://"))
+	return filepath.Join(dir, filepath.FromSlash(escapedPath), "@v", escapedVersion+".zip"), nil
+}
+
+// identifyModuleDir identifies the license at the root of the module extracted in dir.
+func identifyModuleDir(classifier Classifier, logger Logger, dir, modulePath, version string) (*ModuleVersionLicense, error) {
+	candidates, err := FindCandidates(dir, dir)
+	if err != nil {
+		return nil, err
+	}
+	return identifyCandidates(classifier, logger, candidates, modulePath, version), nil
+}
+
+// identifyModuleZip identifies the license at the root of the module in the
+// zip file at zipPath. License candidates are copied to a temporary directory
+// to be classified, the rest of the zip file isn't extracted.
+func identifyModuleZip(classifier Classifier, logger Logger, zipPath, modulePath, version string) (*ModuleVersionLicense, error) {
+	tmpDir, err := os.MkdirTemp("", "go-licenses")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	candidates, err := extractLicenseCandidates(zipPath, modulePath, version, tmpDir)
+	if err != nil {
+		return nil, err
+	}
+	return identifyCandidates(classifier, logger, candidates, modulePath, version), nil
+}
+
+// identifyCandidates returns the license of the first candidate, by rank, that
+// classifier identifies. Candidates that fail to be classified are reported to
+// logger and skipped.
+func identifyCandidates(classifier Classifier, logger Logger, candidates []string, modulePath, version string) *ModuleVersionLicense {
+	lic := &ModuleVersionLicense{Path: modulePath, Version: version}
+	for _, candidate := range RankCandidates(candidates) {
+		licenses, err := classifier.Identify(candidate)
+		if err != nil {
+			logger.Errorf("Failed to parse %s: %v", candidate, err)
+			continue
+		}
+		if len(licenses) > 0 {
+			lic.LicenseFile = filepath.Base(candidate)
+			lic.Licenses = licenses
+			break
+		}
+	}
+	return lic
+}
+
+// extractLicenseCandidates copies the license candidates at the root of the
+// module in the zip file at zipPath to destDir, and returns their paths. The
+// rest of the zip file isn't extracted.
+func extractLicenseCandidates(zipPath, modulePath, version, destDir string) ([]string, error) {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s: %w", zipPath, fs.ErrNotExist)
+		}
+		return nil, err
+	}
+	defer r.Close()
+
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return nil, err
+	}
+	// Files of a module zip are prefixed with "<module>@<version>/".
+	prefix := modulePath + "@" + version + "/"
+	var candidates []string
+	for _, f := range r.File {
+		name := strings.TrimPrefix(f.Name, prefix)
+		if name == f.Name || strings.Contains(name, "/") || !licenseRegexp.MatchString(name) {
+			continue
+		}
+		candidate := filepath.Join(destDir, name)
+		if err := extractZipFile(f, candidate); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, candidate)
+	}
+	sort.Strings(candidates)
+	return candidates, nil
+}
+
+// extractZipFile writes the content of f to dest.
+func extractZipFile(f *zip.File, dest string) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	w, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
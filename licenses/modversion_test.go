@@ -0,0 +1,128 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIdentifyModuleVersion(t *testing.T) {
+	licenses := map[string][]License{
+		"mit":    {{Name: "MIT", Type: Notice}},
+		"apache": {{Name: "Apache-2.0", Type: Notice}},
+	}
+	classifier := classifierFunc(func(path string) ([]License, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if string(data) == "garbled" {
+			return nil, errors.New("cannot classify")
+		}
+		return licenses[string(data)], nil
+	})
+	proxyDir := t.TempDir()
+	writeTestZip(t, filepath.Join(proxyDir, "example.com", "!foo", "@v", "v1.0.0.zip"), map[string]string{
+		"example.com/Foo@v1.0.0/README.md":      "apache",
+		"example.com/Foo@v1.0.0/LICENSE":        "mit",
+		"example.com/Foo@v1.0.0/sub/LICENSE":    "apache",
+		"example.com/Foo@v1.0.0/go.mod":         "module example.com/Foo\n",
+		"example.com/Foo@v1.0.0/sub/COPYING.md": "apache",
+	})
+	modCache := t.TempDir()
+	t.Setenv("GOMODCACHE", modCache)
+	modDir := filepath.Join(modCache, "example.com", "!foo@v2.0.0")
+	if err := os.MkdirAll(modDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(modDir, "COPYING"), []byte("apache"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeTestZip(t, filepath.Join(modCache, "cache", "download", "example.com", "!foo", "@v", "v3.0.0.zip"), map[string]string{
+		"example.com/Foo@v3.0.0/LICENSE.txt": "mit",
+	})
+	writeTestZip(t, filepath.Join(modCache, "cache", "download", "example.com", "!foo", "@v", "v4.0.0.zip"), map[string]string{
+		"example.com/Foo@v4.0.0/COPYING": "garbled",
+		"example.com/Foo@v4.0.0/LICENSE": "apache",
+	})
+
+	for _, test := range []struct {
+		desc         string
+		proxyDir     string
+		version      string
+		want         *ModuleVersionLicense
+		wantMessages []string // Logged messages, with the paths of candidates relative to their directory.
+	}{
+		{
+			desc:     "Proxy directory",
+			proxyDir: proxyDir,
+			version:  "v1.0.0",
+			want:     &ModuleVersionLicense{Path: "example.com/Foo", Version: "v1.0.0", LicenseFile: "LICENSE", Licenses: licenses["mit"]},
+		},
+		{
+			desc:     "Proxy URL",
+			proxyDir: "file://" + filepath.ToSlash(proxyDir),
+			version:  "v1.0.0",
+			want:     &ModuleVersionLicense{Path: "example.com/Foo", Version: "v1.0.0", LicenseFile: "LICENSE", Licenses: licenses["mit"]},
+		},
+		{
+			desc:    "Extracted in the module cache",
+			version: "v2.0.0",
+			want:    &ModuleVersionLicense{Path: "example.com/Foo", Version: "v2.0.0", LicenseFile: "COPYING", Licenses: licenses["apache"]},
+		},
+		{
+			desc:    "Zip in the module cache",
+			version: "v3.0.0",
+			want:    &ModuleVersionLicense{Path: "example.com/Foo", Version: "v3.0.0", LicenseFile: "LICENSE.txt", Licenses: licenses["mit"]},
+		},
+		{
+			desc:         "License file failing to be classified",
+			version:      "v4.0.0",
+			want:         &ModuleVersionLicense{Path: "example.com/Foo", Version: "v4.0.0", LicenseFile: "LICENSE", Licenses: licenses["apache"]},
+			wantMessages: []string{"E Failed to parse COPYING: cannot classify"},
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			logger := &recordingLogger{}
+			got, err := IdentifyModuleVersion(context.Background(), classifier, Options{Logger: logger}, test.proxyDir, "example.com/Foo", test.version)
+			if err != nil {
+				t.Fatalf("IdentifyModuleVersion() = (_, %v), want (_, nil)", err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("IdentifyModuleVersion() diff (-want +got): %s", diff)
+			}
+			// Candidates are extracted to a temporary directory.
+			var gotMessages []string
+			for _, m := range logger.messages {
+				gotMessages = append(gotMessages, regexp.MustCompile(`\S*/`).ReplaceAllString(m, ""))
+			}
+			if diff := cmp.Diff(test.wantMessages, gotMessages); diff != "" {
+				t.Errorf("IdentifyModuleVersion() logged messages diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := IdentifyModuleVersion(context.Background(), classifier, Options{}, proxyDir, "example.com/Foo", "v9.0.0"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("IdentifyModuleVersion() for a missing version = (_, %v), want (_, fs.ErrNotExist)", err)
+	}
+}
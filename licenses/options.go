@@ -0,0 +1,138 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+// Options configures how LoadLibraries finds libraries.
+// The zero value loads packages like "go build" would in the current directory.
+type Options struct {
+	// IncludeTests includes packages only imported by testing code.
+	IncludeTests bool
+	// IgnoredPaths are package path prefixes to be ignored. Dependencies of the
+	// ignored packages are still loaded.
+	IgnoredPaths []string
+
+	// Dir is the directory in which to load packages. If empty, the current
+	// directory is used.
+	Dir string
+	// Env is the environment of the go command. If nil, the current environment is used.
+	Env []string
+	// BuildFlags are passed to the go command, e.g. "-mod=vendor".
+	BuildFlags []string
+	// Tags are build tags, passed to the go command as a -tags flag.
+	Tags []string
+
+	// ScanAssets looks for licenses of non-Go source files, embedded files and
+	// bundled third-party directories of packages, which are returned as
+	// sub-libraries of the libraries containing them. See Library.Parent.
+	ScanAssets bool
+
+	// VerifySums checks that the content of each module used matches the hash
+	// recorded in the main module's go.sum, so that a tampered module cache
+	// can't change the licenses found. Modules are hashed like "go mod verify"
+	// does, which reads all their files.
+	VerifySums SumVerification
+
+	// Concurrency is the maximum number of license files classified, or modules
+	// verified, at the same time. If zero or negative, there is no limit.
+	Concurrency int
+	// Logger receives the warnings and errors found while loading libraries.
+	// If nil, they are logged with klog.
+	Logger Logger
+	// Progress, if not nil, is called as libraries are loaded.
+	Progress func(Progress)
+}
+
+// SumVerification is what to do when the content of a module doesn't match go.sum.
+type SumVerification int
+
+const (
+	// SkipSumVerification doesn't verify module contents.
+	SkipSumVerification SumVerification = iota
+	// WarnOnSumMismatch logs a warning for each module whose content doesn't
+	// match go.sum, or that has no hash in go.sum.
+	WarnOnSumMismatch
+	// FailOnSumMismatch logs an error for each module whose content doesn't
+	// match go.sum, or that has no hash in go.sum, and fails.
+	FailOnSumMismatch
+)
+
+// Logger receives warnings and errors that don't stop LoadLibraries.
+type Logger interface {
+	Warningf(format string, args ...any)
+	Errorf(format string, args ...any)
+}
+
+// ProgressStage is a stage of LoadLibraries.
+type ProgressStage string
+
+const (
+	// StageLoadPackages is when the packages and their dependencies are loaded.
+	StageLoadPackages ProgressStage = "load packages"
+	// StageClassify is when license file candidates are classified.
+	StageClassify ProgressStage = "classify licenses"
+	// StageVerifySums is when module contents are verified against go.sum.
+	StageVerifySums ProgressStage = "verify sums"
+)
+
+// Progress reports how much of a stage of LoadLibraries is done.
+type Progress struct {
+	Stage ProgressStage
+	Done  int
+	Total int
+}
+
+func (p Progress) String() string {
+	return fmt.Sprintf("%s: %d/%d", p.Stage, p.Done, p.Total)
+}
+
+// buildFlags returns the flags of the go command, including the -tags flag.
+func (o Options) buildFlags() []string {
+	flags := append([]string{}, o.BuildFlags...)
+	if len(o.Tags) > 0 {
+		flags = append(flags, "-tags="+strings.Join(o.Tags, ","))
+	}
+	return flags
+}
+
+func (o Options) logger() Logger {
+	if o.Logger == nil {
+		return klogLogger{}
+	}
+	return o.Logger
+}
+
+func (o Options) progress(stage ProgressStage, done, total int) {
+	if o.Progress != nil {
+		o.Progress(Progress{Stage: stage, Done: done, Total: total})
+	}
+}
+
+// klogLogger logs with klog, attributing messages to the caller of the logger.
+type klogLogger struct{}
+
+func (klogLogger) Warningf(format string, args ...any) {
+	klog.WarningDepth(1, fmt.Sprintf(format, args...))
+}
+
+func (klogLogger) Errorf(format string, args ...any) {
+	klog.ErrorDepth(1, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,256 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// ProxyLibraries returns a library for each module required by the main module
+// of opts.Dir, without loading packages. Licenses are identified from the zip
+// files of the modules in proxyDir, a directory laid out like a GOPROXY, i.e.
+// holding <module>/@v/<version>.zip files. proxyDir may be a file:// URL.
+// Modules are neither downloaded nor extracted into the module cache: only their
+// license file candidates are copied, to a sub-directory of licenseDir named
+// after the module version, which becomes the Dir of the library's module.
+// licenseDir should be a new directory, e.g. a temporary one, kept while the
+// libraries are used. The source of the modules isn't available, except through
+// Library.ModuleZip.
+//
+// The main module's go.mod must list all the modules needed to build its
+// packages, as it does since Go 1.17. All of them are returned, including the
+// modules only needed by tests or by packages that aren't built, and each library
+// is a whole module with the license at its root. Replace directives are applied,
+// and local replacements are read from their directory. With opts.VerifySums, the zip
+// files are verified against go.sum. Options other than Dir, Env, IgnoredPaths,
+// VerifySums, Concurrency, Logger and Progress are not used.
+//
+// Modules whose zip file is missing are reported to opts.Logger, and an error
+// wrapping fs.ErrNotExist is returned after all the others were classified.
+func ProxyLibraries(ctx context.Context, classifier Classifier, opts Options, proxyDir, licenseDir string) ([]*Library, error) {
+	logger := opts.logger()
+	goMod, err := mainGoMod(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(goMod)
+	if err != nil {
+		return nil, err
+	}
+	mf, err := modfile.Parse(goMod, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	if mf.Module == nil {
+		return nil, fmt.Errorf("%s: missing module directive", goMod)
+	}
+	mainDir := filepath.Dir(goMod)
+	goSum, err := ReadGoSum(filepath.Join(mainDir, "go.sum"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		logger.Warningf("Failed to read go.sum of module %s: %v", mf.Module.Mod.Path, err)
+	}
+
+	ignored := func(path string) bool {
+		for _, i := range opts.IgnoredPaths {
+			if strings.HasPrefix(path, i) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var modules []*Module
+	for _, req := range mf.Require {
+		if !ignored(req.Mod.Path) {
+			modules = append(modules, proxyModule(mf, req))
+		}
+	}
+	// Missing zip files are reported below.
+	verifyZip := func(m *Module, sum GoSum) error {
+		zipPath, err := proxyZipPath(proxyDir, m.Path, m.fullVersion())
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(zipPath); errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		_, err = verifyModuleZip(zipPath, m.Path, m.fullVersion(), sum)
+		return err
+	}
+	if err := verifyModuleSums(ctx, opts, modules, goSum, verifyZip); err != nil {
+		return nil, err
+	}
+
+	var libraries []*Library
+	total := len(modules) + 1
+	opts.progress(StageClassify, 0, total)
+	if !ignored(mf.Module.Mod.Path) {
+		lib, err := dirLibrary(classifier, logger, &Module{Path: mf.Module.Mod.Path, Dir: mainDir, Main: true}, mainDir)
+		if err != nil {
+			return nil, err
+		}
+		lib.depth = 0
+		libraries = append(libraries, lib)
+	}
+	opts.progress(StageClassify, 1, total)
+
+	var missing []string
+	for i, m := range modules {
+		lib, err := proxyLibrary(classifier, logger, m, mainDir, proxyDir, licenseDir)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			logger.Errorf("Module %s@%s is missing from proxy dir %s", m.Path, m.fullVersion(), proxyDir)
+			missing = append(missing, m.Path+"@"+m.fullVersion())
+		case err != nil:
+			return nil, err
+		default:
+			if hash, ok := goSum.Hash(m.Path, m.fullVersion()); ok {
+				lib.module.Sum = hash
+			}
+			libraries = append(libraries, lib)
+		}
+		opts.progress(StageClassify, i+2, total)
+	}
+
+	sort.Slice(libraries, func(i, j int) bool {
+		return libraries[i].Name() < libraries[j].Name()
+	})
+	if len(missing) > 0 {
+		return libraries, fmt.Errorf("modules missing from proxy dir %s: %s: %w", proxyDir, strings.Join(missing, ", "), fs.ErrNotExist)
+	}
+	return libraries, nil
+}
+
+// mainGoMod returns the path of the go.mod file of the main module of opts.Dir.
+func mainGoMod(ctx context.Context, opts Options) (string, error) {
+	cmd := exec.CommandContext(ctx, "go", "env", "GOMOD")
+	cmd.Dir = opts.Dir
+	cmd.Env = opts.Env
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("go env GOMOD: %w", err)
+	}
+	goMod := strings.TrimSpace(string(out))
+	if goMod == "" || goMod == os.DevNull {
+		return "", fmt.Errorf("cannot find go.mod: not in a Go module")
+	}
+	return goMod, nil
+}
+
+// proxyModule returns the module required by req, after applying the replace
+// directives of mf.
+func proxyModule(mf *modfile.File, req *modfile.Require) *Module {
+	version := strings.TrimSuffix(req.Mod.Version, "+incompatible")
+	m := &Module{
+		Path:       req.Mod.Path,
+		Version:    version,
+		Indirect:   req.Indirect,
+		rawVersion: req.Mod.Version,
+	}
+	for _, r := range mf.Replace {
+		// A replace directive without version applies to all versions, but one
+		// with a version takes precedence.
+		if r.Old.Path != req.Mod.Path || (r.Old.Version != "" && r.Old.Version != req.Mod.Version) {
+			continue
+		}
+		replaced := &Module{
+			Path:       r.New.Path,
+			Version:    strings.TrimSuffix(r.New.Version, "+incompatible"),
+			Indirect:   req.Indirect,
+			rawVersion: r.New.Version,
+			Original:   &Module{Path: req.Mod.Path, Version: version, rawVersion: req.Mod.Version},
+		}
+		if r.Old.Version != "" {
+			return replaced
+		}
+		m = replaced
+	}
+	return m
+}
+
+// proxyLibrary returns the library of module m, read from its zip file in
+// proxyDir, or from its directory when it's replaced by a local one.
+func proxyLibrary(classifier Classifier, logger Logger, m *Module, mainDir, proxyDir, licenseDir string) (*Library, error) {
+	if m.Original != nil && m.Version == "" {
+		dir := m.Path
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(mainDir, dir)
+		}
+		m.Dir = dir
+		return dirLibrary(classifier, logger, m, dir)
+	}
+	zipPath, err := proxyZipPath(proxyDir, m.Path, m.fullVersion())
+	if err != nil {
+		return nil, err
+	}
+	escapedPath, err := module.EscapePath(m.Path)
+	if err != nil {
+		return nil, err
+	}
+	escapedVersion, err := module.EscapeVersion(m.fullVersion())
+	if err != nil {
+		return nil, err
+	}
+	m.Dir = filepath.Join(licenseDir, filepath.FromSlash(escapedPath)+"@"+escapedVersion)
+	if _, err := extractLicenseCandidates(zipPath, m.Path, m.fullVersion(), m.Dir); err != nil {
+		return nil, err
+	}
+	lib, err := dirLibrary(classifier, logger, m, m.Dir)
+	if err != nil {
+		return nil, err
+	}
+	lib.moduleZip = zipPath
+	return lib, nil
+}
+
+// dirLibrary returns the library of module m, with the license found at the
+// root of dir.
+func dirLibrary(classifier Classifier, logger Logger, m *Module, dir string) (*Library, error) {
+	lic, err := identifyModuleDir(classifier, logger, dir, m.Path, m.Version)
+	if err != nil {
+		return nil, err
+	}
+	// Packages import the module by its path before replacement.
+	importPath := m.Path
+	if m.Original != nil {
+		importPath = m.Original.Path
+	}
+	lib := &Library{
+		Packages: []string{importPath},
+		module:   m,
+		Licenses: lic.Licenses,
+		depth:    1,
+	}
+	if m.Indirect {
+		// The go.mod file doesn't tell which module imports an indirect one, so
+		// it's at least one module boundary further.
+		lib.depth = 2
+	}
+	if lic.LicenseFile != "" {
+		lib.LicenseFile = filepath.Join(dir, lic.LicenseFile)
+	}
+	return lib, nil
+}
@@ -0,0 +1,219 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/mod/modfile"
+)
+
+func TestProxyLibraries(t *testing.T) {
+	licenses := map[string][]License{
+		"mit":    {{Name: "MIT", Type: Notice}},
+		"apache": {{Name: "Apache-2.0", Type: Notice}},
+		"bsd":    {{Name: "BSD-3-Clause", Type: Notice}},
+	}
+	classifier := classifierFunc(func(path string) ([]License, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return licenses[string(data)], nil
+	})
+	mainDir := t.TempDir()
+	files := map[string]string{
+		"go.mod": `module example.com/main
+
+go 1.21
+
+require (
+	example.com/direct v1.0.0
+	example.com/indirect v1.2.0 // indirect
+	example.com/replaced v1.0.0
+	example.com/local v1.0.0
+	example.com/ignored v1.0.0
+)
+
+replace example.com/replaced => example.com/fork v1.1.0
+
+replace example.com/local => ./local
+`,
+		"go.sum":        "example.com/direct v1.0.0 h1:direct=\nexample.com/direct v1.0.0/go.mod h1:directmod=\n",
+		"LICENSE":       "apache",
+		"local/LICENSE": "bsd",
+	}
+	for name, content := range files {
+		path := filepath.Join(mainDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	proxyDir := t.TempDir()
+	for _, m := range []struct{ path, version, license string }{
+		{"example.com/direct", "v1.0.0", "mit"},
+		{"example.com/fork", "v1.1.0", "bsd"},
+		{"example.com/ignored", "v1.0.0", "mit"},
+	} {
+		zipPath, err := proxyZipPath(proxyDir, m.path, m.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		prefix := m.path + "@" + m.version + "/"
+		writeTestZip(t, zipPath, map[string]string{
+			prefix + "LICENSE": m.license,
+			prefix + "a/a.go":  "package a",
+		})
+	}
+	licenseDir := t.TempDir()
+
+	logger := &recordingLogger{}
+	libs, err := ProxyLibraries(context.Background(), classifier, Options{
+		Dir:          mainDir,
+		IgnoredPaths: []string{"example.com/ignored"},
+		Logger:       logger,
+	}, "file://"+proxyDir, licenseDir)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ProxyLibraries() error = %v, want one wrapping fs.ErrNotExist for example.com/indirect", err)
+	}
+	wantMessages := []string{"E Module example.com/indirect@v1.2.0 is missing from proxy dir file://" + proxyDir}
+	if diff := cmp.Diff(wantMessages, logger.messages); diff != "" {
+		t.Errorf("ProxyLibraries() logged messages diff (-want +got):\n%s", diff)
+	}
+
+	type result struct {
+		Name, LicenseFile, Sum string
+		ModuleZip              string
+		Licenses               []License
+		Depth                  int
+		Original               string
+	}
+	var got []result
+	for _, lib := range libs {
+		r := result{
+			Name:        lib.Name(),
+			LicenseFile: lib.LicenseFile,
+			Licenses:    lib.Licenses,
+			Depth:       lib.Depth(),
+			Sum:         lib.Module().Sum,
+			ModuleZip:   lib.moduleZip,
+		}
+		if original := lib.Module().Original; original != nil {
+			r.Original = original.Path + "@" + original.Version
+		}
+		got = append(got, r)
+	}
+	want := []result{
+		{
+			Name:        "example.com/direct",
+			LicenseFile: filepath.Join(licenseDir, "example.com", "direct@v1.0.0", "LICENSE"),
+			Sum:         "h1:direct=",
+			ModuleZip:   filepath.Join(proxyDir, "example.com", "direct", "@v", "v1.0.0.zip"),
+			Licenses:    []License{{Name: "MIT", Type: Notice}},
+			Depth:       1,
+		},
+		{
+			Name:        "example.com/local",
+			LicenseFile: filepath.Join(mainDir, "local", "LICENSE"),
+			Licenses:    []License{{Name: "BSD-3-Clause", Type: Notice}},
+			Depth:       1,
+			Original:    "example.com/local@v1.0.0",
+		},
+		{
+			Name:        "example.com/main",
+			LicenseFile: filepath.Join(mainDir, "LICENSE"),
+			Licenses:    []License{{Name: "Apache-2.0", Type: Notice}},
+			Depth:       0,
+		},
+		{
+			Name:        "example.com/replaced",
+			LicenseFile: filepath.Join(licenseDir, "example.com", "fork@v1.1.0", "LICENSE"),
+			ModuleZip:   filepath.Join(proxyDir, "example.com", "fork", "@v", "v1.1.0.zip"),
+			Licenses:    []License{{Name: "BSD-3-Clause", Type: Notice}},
+			Depth:       1,
+			Original:    "example.com/replaced@v1.0.0",
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ProxyLibraries() libraries diff (-want +got):\n%s", diff)
+	}
+	if _, err := os.Stat(filepath.Join(licenseDir, "example.com", "direct@v1.0.0", "a")); !os.IsNotExist(err) {
+		t.Errorf("ProxyLibraries() extracted more than license files: %v", err)
+	}
+}
+
+func TestProxyModule(t *testing.T) {
+	for _, test := range []struct {
+		desc     string
+		replaces string
+		want     *Module
+	}{
+		{
+			desc: "Not replaced",
+			want: &Module{Path: "example.com/a", Version: "v1.0.0", rawVersion: "v1.0.0"},
+		},
+		{
+			desc:     "Replaced without version",
+			replaces: "replace example.com/a => example.com/fork v1.1.0\n",
+			want: &Module{
+				Path: "example.com/fork", Version: "v1.1.0", rawVersion: "v1.1.0",
+				Original: &Module{Path: "example.com/a", Version: "v1.0.0", rawVersion: "v1.0.0"},
+			},
+		},
+		{
+			desc:     "Replaced with other version",
+			replaces: "replace example.com/a v0.9.0 => example.com/fork v1.1.0\n",
+			want:     &Module{Path: "example.com/a", Version: "v1.0.0", rawVersion: "v1.0.0"},
+		},
+		{
+			desc: "Versioned replace takes precedence",
+			replaces: "replace example.com/a => ./local\n" +
+				"replace example.com/a v1.0.0 => example.com/fork v1.1.0\n",
+			want: &Module{
+				Path: "example.com/fork", Version: "v1.1.0", rawVersion: "v1.1.0",
+				Original: &Module{Path: "example.com/a", Version: "v1.0.0", rawVersion: "v1.0.0"},
+			},
+		},
+		{
+			desc: "Versioned replace takes precedence regardless of order",
+			replaces: "replace example.com/a v1.0.0 => example.com/fork v1.1.0\n" +
+				"replace example.com/a => ./local\n",
+			want: &Module{
+				Path: "example.com/fork", Version: "v1.1.0", rawVersion: "v1.1.0",
+				Original: &Module{Path: "example.com/a", Version: "v1.0.0", rawVersion: "v1.0.0"},
+			},
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			mf, err := modfile.Parse("go.mod", []byte("module example.com/main\n\nrequire example.com/a v1.0.0\n\n"+test.replaces), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := proxyModule(mf, mf.Require[0])
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(Module{})); diff != "" {
+				t.Errorf("proxyModule() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
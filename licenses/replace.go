@@ -0,0 +1,104 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/module"
+)
+
+// IsLocalReplacement returns true if the module was replaced by a directory
+// on the local file system, e.g. "replace foo => ../foo", rather than by
+// another module version.
+func (m *Module) IsLocalReplacement() bool {
+	return m != nil && m.Original != nil && m.Version == ""
+}
+
+// UpstreamLicenses identifies the licenses of the library in the module it
+// replaces, when its module is replaced. The upstream module must already be
+// in the module cache (GOMODCACHE), e.g. by running "go mod download" for it.
+// The path of the upstream license file is returned too.
+//
+// When the module isn't replaced or the upstream module isn't in the module
+// cache, the error wraps fs.ErrNotExist.
+func (l *Library) UpstreamLicenses(ctx context.Context, classifier Classifier) ([]License, string, error) {
+	if l == nil {
+		return nil, "", fmt.Errorf("library is nil")
+	}
+	wrap := func(err error) error {
+		return fmt.Errorf("finding upstream license of library %s: %w", l.Name(), err)
+	}
+	m := l.module
+	if m == nil || m.Original == nil {
+		return nil, "", wrap(fmt.Errorf("module is not replaced: %w", fs.ErrNotExist))
+	}
+	modCache, err := goEnv(ctx, "GOMODCACHE")
+	if err != nil {
+		return nil, "", wrap(err)
+	}
+	upstreamDir, err := moduleDirPath(modCache, m.Original.Path, m.Original.fullVersion())
+	if err != nil {
+		return nil, "", wrap(err)
+	}
+	if _, err := os.Stat(upstreamDir); err != nil {
+		return nil, "", wrap(fmt.Errorf("%w, run \"go mod download %s@%s\"", err, m.Original.Path, m.Original.fullVersion()))
+	}
+
+	// Look for the license where the replacement has it, falling back to the
+	// upstream module root when that directory doesn't exist upstream.
+	dir := upstreamDir
+	if l.LicenseFile != "" && m.Dir != "" {
+		if rel, err := filepath.Rel(m.Dir, filepath.Dir(l.LicenseFile)); err == nil && !strings.HasPrefix(rel, "..") {
+			if _, err := os.Stat(filepath.Join(upstreamDir, rel)); err == nil {
+				dir = filepath.Join(upstreamDir, rel)
+			}
+		}
+	}
+	candidates, err := FindCandidates(dir, upstreamDir)
+	if err != nil {
+		return nil, "", wrap(err)
+	}
+	// Candidates are ranked like those of the library.
+	for _, candidate := range RankCandidates(candidates) {
+		licenses, err := classifier.Identify(candidate)
+		if err != nil {
+			return nil, "", wrap(err)
+		}
+		if len(licenses) > 0 {
+			return licenses, candidate, nil
+		}
+	}
+	return nil, "", nil
+}
+
+// moduleDirPath returns the path of the extracted module version in the
+// module cache at modCache.
+func moduleDirPath(modCache, modulePath, version string) (string, error) {
+	escapedPath, err := module.EscapePath(modulePath)
+	if err != nil {
+		return "", err
+	}
+	escapedVersion, err := module.EscapeVersion(version)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(modCache, filepath.FromSlash(escapedPath)+"@"+escapedVersion), nil
+}
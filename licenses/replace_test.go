@@ -0,0 +1,88 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLibraryUpstreamLicenses(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Cannot get working directory: %v", err)
+	}
+	modCache := t.TempDir()
+	t.Setenv("GOMODCACHE", modCache)
+	upstreamLicense := filepath.Join(modCache, "example.com", "!upstream@v1.0.0", "LICENSE")
+	if err := os.MkdirAll(filepath.Dir(upstreamLicense), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(upstreamLicense, []byte("MIT license\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	upstreamClassifier := classifierFunc(func(path string) ([]License, error) {
+		if path == upstreamLicense {
+			return []License{{Name: "MIT", Type: Notice}}, nil
+		}
+		return nil, fmt.Errorf("unexpected license file %q", path)
+	})
+
+	lib := &Library{
+		LicenseFile: wd + "/testdata/LICENSE",
+		Packages:    []string{"example.com/Upstream"},
+		module: &Module{
+			Path:     "./testdata",
+			Dir:      wd + "/testdata",
+			Original: &Module{Path: "example.com/Upstream", Version: "v1.0.0"},
+		},
+	}
+	if !lib.module.IsLocalReplacement() {
+		t.Errorf("IsLocalReplacement() = false, want true")
+	}
+	got, gotPath, err := lib.UpstreamLicenses(context.Background(), upstreamClassifier)
+	if err != nil {
+		t.Fatalf("UpstreamLicenses() = (_, _, %v), want (_, _, nil)", err)
+	}
+	if diff := cmp.Diff([]License{{Name: "MIT", Type: Notice}}, got); diff != "" {
+		t.Errorf("UpstreamLicenses() diff (-want +got): %s", diff)
+	}
+	if gotPath != upstreamLicense {
+		t.Errorf("UpstreamLicenses() path = %q, want %q", gotPath, upstreamLicense)
+	}
+
+	lib.module.Original.Version = "v2.0.0"
+	if _, _, err := lib.UpstreamLicenses(context.Background(), upstreamClassifier); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("UpstreamLicenses() for a module not in the cache = (_, _, %v), want (_, _, fs.ErrNotExist)", err)
+	}
+
+	lib.module.Original = nil
+	if lib.module.IsLocalReplacement() {
+		t.Errorf("IsLocalReplacement() = true for a module that isn't replaced, want false")
+	}
+}
+
+type classifierFunc func(path string) ([]License, error)
+
+func (f classifierFunc) Identify(path string) ([]License, error) {
+	return f(path)
+}
@@ -0,0 +1,114 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// LicenseSelection explains how the license file of a library was selected
+// among the license file candidates of its packages.
+type LicenseSelection struct {
+	// Candidates are the candidates identified as containing a license, ranked
+	// best first: by kind, then nearest to the package first. The first one is
+	// the library's LicenseFile.
+	Candidates []LicenseCandidate
+}
+
+// LicenseCandidate is a license file candidate identified as containing a license.
+type LicenseCandidate struct {
+	Path     string
+	Kind     CandidateKind
+	Licenses []License
+	// Distance is the number of directories between the package directory and
+	// the candidate, 0 when the candidate is in the package directory.
+	Distance int
+}
+
+func (c LicenseCandidate) String() string {
+	var names []string
+	for _, license := range c.Licenses {
+		names = append(names, license.Name)
+	}
+	return fmt.Sprintf("%s (%s: %s)", c.Path, c.Kind, strings.Join(names, ", "))
+}
+
+// newLicenseSelection ranks the candidates of the package in pkgDir that are
+// in found, the licenses identified by candidate path.
+func newLicenseSelection(pkgDir string, candidates []string, found map[string][]License) *LicenseSelection {
+	s := &LicenseSelection{}
+	for _, candidate := range RankCandidates(candidates) {
+		licenses, ok := found[candidate]
+		if !ok {
+			continue
+		}
+		distance := 0
+		if rel, err := filepath.Rel(filepath.Dir(candidate), pkgDir); err == nil && rel != "." {
+			distance = strings.Count(rel, string(filepath.Separator)) + 1
+		}
+		s.Candidates = append(s.Candidates, LicenseCandidate{
+			Path:     candidate,
+			Kind:     KindOf(candidate),
+			Licenses: licenses,
+			Distance: distance,
+		})
+	}
+	return s
+}
+
+// Selected returns the selected candidate, or nil if no candidate was identified.
+func (s *LicenseSelection) Selected() *LicenseCandidate {
+	if s == nil || len(s.Candidates) == 0 {
+		return nil
+	}
+	return &s.Candidates[0]
+}
+
+// Conflicts returns the candidates at least as near to the package as the
+// selected one whose licenses differ from the selected one's, e.g. a README
+// mentioning another license next to the package. Candidates further away are
+// expected to differ, e.g. the license of the module root may not apply to a
+// directory with a license of its own.
+func (s *LicenseSelection) Conflicts() []LicenseCandidate {
+	selected := s.Selected()
+	if selected == nil {
+		return nil
+	}
+	var conflicts []LicenseCandidate
+	for _, c := range s.Candidates[1:] {
+		if c.Distance <= selected.Distance && !sameLicenseNames(c.Licenses, selected.Licenses) {
+			conflicts = append(conflicts, c)
+		}
+	}
+	return conflicts
+}
+
+func (s *LicenseSelection) String() string {
+	selected := s.Selected()
+	if selected == nil {
+		return "no license candidate identified"
+	}
+	str := fmt.Sprintf("selected %s", selected)
+	if conflicts := s.Conflicts(); len(conflicts) > 0 {
+		var others []string
+		for _, c := range conflicts {
+			others = append(others, c.String())
+		}
+		str += fmt.Sprintf(" over %s", strings.Join(others, ", "))
+	}
+	return str
+}
@@ -0,0 +1 @@
+/* header.js is distributed under a license of its own. */
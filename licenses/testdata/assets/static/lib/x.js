@@ -0,0 +1 @@
+var x = 1;
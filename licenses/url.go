@@ -0,0 +1,144 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-licenses/v2/internal/third_party/pkgsite/source"
+	"golang.org/x/mod/module"
+	"k8s.io/klog/v2"
+)
+
+const (
+	pkgGoDevURL     = "https://pkg.go.dev"
+	defaultProxyURL = "https://proxy.golang.org"
+)
+
+// Templates accepted by TemplateFileURL for well-known locations.
+const (
+	// PkgGoDevURLTemplate points to the licenses tab of the module on pkg.go.dev.
+	PkgGoDevURLTemplate = pkgGoDevURL + "/{module}@{version}?tab=licenses"
+	// ProxyZipURLTemplate points to the module zip hosted by the Go module proxy.
+	ProxyZipURLTemplate = defaultProxyURL + "/{module_escaped}/@v/{version_escaped}.zip"
+)
+
+// FallbackURLs returns URLs that can be used to refer to the library's license
+// when the URL returned by FileURL is broken. They are ordered by preference:
+// the licenses tab on pkg.go.dev, then the module zip hosted by the Go module proxy.
+// Modules replaced by a local directory have no fallback URL.
+func (l *Library) FallbackURLs() []string {
+	if l == nil || l.module == nil || l.module.Path == "" || l.module.IsLocalReplacement() {
+		return nil
+	}
+	if l.module.Version == "" {
+		// The main module has no version, so only pkg.go.dev can point to it.
+		return []string{fmt.Sprintf("%s/%s?tab=licenses", pkgGoDevURL, l.module.Path)}
+	}
+	var urls []string
+	for _, tmpl := range []string{PkgGoDevURLTemplate, ProxyZipURLTemplate} {
+		if url, err := l.TemplateFileURL(tmpl, ""); err == nil {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// TemplateFileURL returns a URL for filePath in this library by expanding the
+// placeholders in tmpl with the library's module information. It does not need
+// to resolve the module's repository, so it works for any module with a version.
+// Modules without a version, like the main module, result in an error: use
+// FallbackURLs for them instead.
+//
+// Supported placeholders are:
+//   - {module}: the module path, e.g. github.com/BurntSushi/toml.
+//   - {version}: the module version, e.g. v1.2.3, including any +incompatible suffix.
+//   - {module_escaped}, {version_escaped}: the same, case-encoded as in the
+//     module proxy protocol, e.g. github.com/!burnt!sushi/toml.
+//   - {file}: the path of filePath relative to the module root, or empty when
+//     filePath is empty.
+func (l *Library) TemplateFileURL(tmpl, filePath string) (string, error) {
+	if l == nil {
+		return "", fmt.Errorf("library is nil")
+	}
+	wrap := func(err error) error {
+		return fmt.Errorf("expanding URL template in library %s: %w", l.Name(), err)
+	}
+	m := l.module
+	if m == nil || m.Path == "" {
+		return "", wrap(fmt.Errorf("empty go module info"))
+	}
+	version := m.fullVersion()
+	if version == "" {
+		return "", wrap(fmt.Errorf("module %s has empty version", m.Path))
+	}
+	escapedPath, err := module.EscapePath(m.Path)
+	if err != nil {
+		return "", wrap(err)
+	}
+	escapedVersion, err := module.EscapeVersion(version)
+	if err != nil {
+		return "", wrap(err)
+	}
+	file := ""
+	if filePath != "" {
+		if m.Dir == "" {
+			return "", wrap(fmt.Errorf("empty go module dir"))
+		}
+		relativePath, err := filepath.Rel(m.Dir, filePath)
+		if err != nil {
+			return "", wrap(err)
+		}
+		file = filepath.ToSlash(relativePath)
+	}
+	return strings.NewReplacer(
+		"{module}", m.Path,
+		"{version}", version,
+		"{module_escaped}", escapedPath,
+		"{version_escaped}", escapedVersion,
+		"{file}", file,
+	).Replace(tmpl), nil
+}
+
+// VerifiedFileURL returns the URL for filePath like FileURL does, but also checks
+// that the URL exists. If it doesn't, each of FallbackURLs is tried in turn and the
+// first one that exists is returned. An error is returned when none of them can be
+// verified to exist.
+func (l *Library) VerifiedFileURL(ctx context.Context, cl *source.Client, filePath string) (string, error) {
+	var candidates []string
+	fileURL, err := l.FileURL(ctx, cl, filePath)
+	if err == nil {
+		candidates = append(candidates, fileURL)
+	}
+	candidates = append(candidates, l.FallbackURLs()...)
+	if len(candidates) == 0 {
+		return "", err
+	}
+	for _, url := range candidates {
+		exists, err := cl.URLExists(ctx, url)
+		if err != nil {
+			klog.Warningf("Failed to verify URL %s for library %s: %v", url, l.Name(), err)
+			continue
+		}
+		if exists {
+			return url, nil
+		}
+		klog.Warningf("URL %s for library %s does not exist", url, l.Name())
+	}
+	return "", fmt.Errorf("verifying URL for library %s: none of %q could be verified", l.Name(), candidates)
+}
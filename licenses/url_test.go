@@ -0,0 +1,147 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLibraryFallbackURLs(t *testing.T) {
+	for _, test := range []struct {
+		desc     string
+		lib      *Library
+		wantURLs []string
+	}{
+		{
+			desc:     "Library without module",
+			lib:      &Library{Packages: []string{"example.com/project"}},
+			wantURLs: nil,
+		},
+		{
+			desc: "Library with version",
+			lib: &Library{
+				Packages: []string{"github.com/BurntSushi/toml"},
+				module: &Module{
+					Path:    "github.com/BurntSushi/toml",
+					Version: "v1.2.3",
+				},
+			},
+			wantURLs: []string{
+				"https://pkg.go.dev/github.com/BurntSushi/toml@v1.2.3?tab=licenses",
+				"https://proxy.golang.org/github.com/!burnt!sushi/toml/@v/v1.2.3.zip",
+			},
+		},
+		{
+			desc: "Main module without version",
+			lib: &Library{
+				Packages: []string{"github.com/google/go-licenses/v2"},
+				module: &Module{
+					Path: "github.com/google/go-licenses/v2",
+				},
+			},
+			wantURLs: []string{
+				"https://pkg.go.dev/github.com/google/go-licenses/v2?tab=licenses",
+			},
+		},
+		{
+			desc: "Local replacement",
+			lib: &Library{
+				Packages: []string{"github.com/BurntSushi/toml"},
+				module: &Module{
+					Path:     "../toml",
+					Original: &Module{Path: "github.com/BurntSushi/toml", Version: "v1.2.3"},
+				},
+			},
+			wantURLs: nil,
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			if diff := cmp.Diff(test.wantURLs, test.lib.FallbackURLs()); diff != "" {
+				t.Errorf("FallbackURLs() diff (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestLibraryTemplateFileURL(t *testing.T) {
+	lib := &Library{
+		Packages:    []string{"github.com/BurntSushi/toml"},
+		LicenseFile: "/go/pkg/mod/github.com/!burnt!sushi/toml@v1.2.3/COPYING",
+		module: &Module{
+			Path:    "github.com/BurntSushi/toml",
+			Dir:     "/go/pkg/mod/github.com/!burnt!sushi/toml@v1.2.3",
+			Version: "v1.2.3",
+		},
+	}
+	for _, test := range []struct {
+		desc    string
+		lib     *Library
+		tmpl    string
+		path    string
+		wantURL string
+		wantErr bool
+	}{
+		{
+			desc:    "Module proxy zip",
+			lib:     lib,
+			tmpl:    ProxyZipURLTemplate,
+			path:    lib.LicenseFile,
+			wantURL: "https://proxy.golang.org/github.com/!burnt!sushi/toml/@v/v1.2.3.zip",
+		},
+		{
+			desc:    "pkg.go.dev",
+			lib:     lib,
+			tmpl:    PkgGoDevURLTemplate,
+			path:    lib.LicenseFile,
+			wantURL: "https://pkg.go.dev/github.com/BurntSushi/toml@v1.2.3?tab=licenses",
+		},
+		{
+			desc:    "Custom template",
+			lib:     lib,
+			tmpl:    "https://mirror.example.com/{module}/-/blob/{version}/{file}",
+			path:    lib.LicenseFile,
+			wantURL: "https://mirror.example.com/github.com/BurntSushi/toml/-/blob/v1.2.3/COPYING",
+		},
+		{
+			desc:    "Custom template without file",
+			lib:     lib,
+			tmpl:    "https://mirror.example.com/{module}/-/blob/{version}/{file}",
+			wantURL: "https://mirror.example.com/github.com/BurntSushi/toml/-/blob/v1.2.3/",
+		},
+		{
+			desc: "Module without version",
+			lib: &Library{
+				Packages: []string{"github.com/google/go-licenses/v2"},
+				module:   &Module{Path: "github.com/google/go-licenses/v2"},
+			},
+			tmpl:    ProxyZipURLTemplate,
+			wantErr: true,
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			got, err := test.lib.TemplateFileURL(test.tmpl, test.path)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("TemplateFileURL(%q, %q) = (_, %q), want err? %t", test.tmpl, test.path, err, test.wantErr)
+			} else if gotErr {
+				return
+			}
+			if got != test.wantURL {
+				t.Fatalf("TemplateFileURL(%q, %q) = %q, want %q", test.tmpl, test.path, got, test.wantURL)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"golang.org/x/mod/sumdb/dirhash"
+	"golang.org/x/sync/errgroup"
+)
+
+// verifyModuleSums checks that the content of modules matches the hashes
+// recorded in sum, as configured by opts.VerifySums. The main module and
+// modules replaced by a local directory have no hash, so they are skipped.
+func verifyModuleSums(ctx context.Context, opts Options, modules []*Module, sum GoSum, verify func(m *Module, sum GoSum) error) error {
+	if opts.VerifySums == SkipSumVerification {
+		return nil
+	}
+	logger := opts.logger()
+	var toVerify []*Module
+	for _, m := range modules {
+		if !m.Main && m.Version != "" {
+			toVerify = append(toVerify, m)
+		}
+	}
+	sort.Slice(toVerify, func(i, j int) bool {
+		return toVerify[i].Path < toVerify[j].Path
+	})
+
+	group, _ := errgroup.WithContext(ctx)
+	if opts.Concurrency > 0 {
+		group.SetLimit(opts.Concurrency)
+	}
+	var mu sync.Mutex
+	verified := 0
+	errs := make([]error, len(toVerify))
+	opts.progress(StageVerifySums, 0, len(toVerify))
+	for i, m := range toVerify {
+		group.Go(func() error {
+			errs[i] = verify(m, sum)
+			mu.Lock()
+			verified++
+			opts.progress(StageVerifySums, verified, len(toVerify))
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		failed++
+		if opts.VerifySums == FailOnSumMismatch {
+			logger.Errorf("Failed to verify module against go.sum: %v", err)
+		} else {
+			logger.Warningf("Failed to verify module against go.sum: %v", err)
+		}
+	}
+	if failed > 0 && opts.VerifySums == FailOnSumMismatch {
+		return fmt.Errorf("content of %d modules doesn't match go.sum", failed)
+	}
+	return nil
+}
+
+// verifyModuleDir checks that the content of the directory of module m
+// matches the hash recorded in sum.
+func verifyModuleDir(m *Module, sum GoSum) error {
+	version := m.fullVersion()
+	want, ok := sum.Hash(m.Path, version)
+	if !ok {
+		return fmt.Errorf("go.sum has no hash for %s@%s", m.Path, version)
+	}
+	got, err := dirhash.HashDir(m.Dir, m.Path+"@"+version, dirhash.Hash1)
+	if err != nil {
+		return fmt.Errorf("hashing %s: %w", m.Dir, err)
+	}
+	if got != want {
+		return fmt.Errorf("%s has hash %s, but go.sum has %s for %s@%s", m.Dir, got, want, m.Path, version)
+	}
+	return nil
+}
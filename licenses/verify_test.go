@@ -0,0 +1,107 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+func TestVerifyModuleSums(t *testing.T) {
+	writeModule := func(t *testing.T, license string) string {
+		t.Helper()
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "LICENSE"), []byte(license), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+	goodDir := writeModule(t, "mit")
+	tamperedDir := writeModule(t, "mit")
+	sum := GoSum{}
+	for _, m := range []struct{ path, dir string }{
+		{"example.com/good", goodDir},
+		{"example.com/tampered", tamperedDir},
+	} {
+		hash, err := dirhash.HashDir(m.dir, m.path+"@v1.0.0", dirhash.Hash1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum[m.path+"@v1.0.0"] = hash
+	}
+	if err := os.WriteFile(filepath.Join(tamperedDir, "LICENSE"), []byte("apache"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	modules := []*Module{
+		{Path: "example.com/good", Version: "v1.0.0", Dir: goodDir},
+		{Path: "example.com/tampered", Version: "v1.0.0", Dir: tamperedDir},
+		{Path: "example.com/unsummed", Version: "v1.0.0", Dir: goodDir},
+		// Neither the main module nor local replacements have a hash.
+		{Path: "example.com/main", Dir: goodDir, Main: true},
+		{Path: "./local", Dir: goodDir, Original: &Module{Path: "example.com/local", Version: "v1.0.0"}},
+	}
+	tests := []struct {
+		name         string
+		verifySums   SumVerification
+		wantErr      bool
+		wantMessages []string
+	}{
+		{
+			name:       "skip",
+			verifySums: SkipSumVerification,
+		},
+		{
+			name:       "warn",
+			verifySums: WarnOnSumMismatch,
+			wantMessages: []string{
+				"W Failed to verify module against go.sum: " + tamperedDir + " has hash",
+				"W Failed to verify module against go.sum: go.sum has no hash for example.com/unsummed@v1.0.0",
+			},
+		},
+		{
+			name:       "fail",
+			verifySums: FailOnSumMismatch,
+			wantErr:    true,
+			wantMessages: []string{
+				"E Failed to verify module against go.sum: " + tamperedDir + " has hash",
+				"E Failed to verify module against go.sum: go.sum has no hash for example.com/unsummed@v1.0.0",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logger := &recordingLogger{}
+			opts := Options{VerifySums: test.verifySums, Logger: logger}
+			err := verifyModuleSums(context.Background(), opts, modules, sum, verifyModuleDir)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Errorf("verifyModuleSums() = %v, want error: %v", err, test.wantErr)
+			}
+			if len(logger.messages) != len(test.wantMessages) {
+				t.Fatalf("verifyModuleSums() logged %q, want %q", logger.messages, test.wantMessages)
+			}
+			for i, want := range test.wantMessages {
+				if !strings.HasPrefix(logger.messages[i], want) {
+					t.Errorf("verifyModuleSums() logged %q, want prefix %q", logger.messages[i], want)
+				}
+			}
+		})
+	}
+}
@@ -15,10 +15,14 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"os"
 	"strings"
 
+	"github.com/google/go-licenses/v2/licenses"
 	"github.com/spf13/cobra"
 	"k8s.io/klog/v2"
 )
@@ -38,6 +42,9 @@ Prerequisites:
 	// Flags shared between subcommands
 	includeTests bool
 	ignore       []string
+	scanAssets   bool
+	proxyDir     string
+	verifySums   string
 	packageHelp  = `
 
 Typically, specify the Go package that builds your Go binary.
@@ -45,7 +52,8 @@ go-licenses expects the same package argument format as "go build".
 For example:
 * A rooted import path like "github.com/google/go-licenses" or "github.com/google/go-licenses/licenses".
 * A relative path that denotes the package in that directory, like "." or "./cmd/some-command".
-To learn more about Go package argument, run "go help packages".`
+To learn more about Go package argument, run "go help packages".
+With --proxy_dir, no package is specified: all the modules required by the main module are read.`
 )
 
 func init() {
@@ -63,6 +71,9 @@ func init() {
 	}
 	rootCmd.PersistentFlags().BoolVar(&includeTests, "include_tests", false, "Include packages only imported by testing code.")
 	rootCmd.PersistentFlags().StringSliceVar(&ignore, "ignore", nil, "Package path prefixes to be ignored. Dependencies from the ignored packages are still checked. Can be specified multiple times.")
+	rootCmd.PersistentFlags().BoolVar(&scanAssets, "scan_assets", false, "Look for licenses of non-Go code, embedded files and bundled third-party directories, and report them as separate libraries.")
+	rootCmd.PersistentFlags().StringVar(&proxyDir, "proxy_dir", "", "Directory laid out like a GOPROXY, i.e. holding <module>/@v/<version>.zip files, to read modules from instead of the module cache. May be a file:// URL.")
+	rootCmd.PersistentFlags().StringVar(&verifySums, "verify_sums", "off", "Verify the content of each module used against go.sum before trusting its licenses: off, warn or fail on mismatch.")
 }
 
 func main() {
@@ -71,11 +82,70 @@ func main() {
 	rootCmd.SilenceErrors = true // to avoid duplicate error output
 	rootCmd.SilenceUsage = true  // to avoid usage/help output on error
 
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	for _, dir := range tempDirs {
+		os.RemoveAll(dir)
+	}
+	if errors.Is(err, errExitFailure) {
+		os.Exit(1)
+	} else if err != nil {
 		klog.Exit(err)
 	}
 }
 
+// tempDirs are removed once the command is done.
+var tempDirs []string
+
+// errExitFailure is returned by commands that already reported why they fail,
+// e.g. check finding a forbidden license, to exit with status 1 once cleaned up.
+var errExitFailure = errors.New("exit status 1")
+
+// packageArgs checks the package arguments of a command loading libraries. They
+// can't be used with --proxy_dir, which reads the modules of the main module's
+// go.mod instead.
+func packageArgs(cmd *cobra.Command, args []string) error {
+	if proxyDir != "" {
+		if len(args) > 0 {
+			return fmt.Errorf("package arguments %q can't be used with --proxy_dir, which reads all the modules required by the main module's go.mod", args)
+		}
+		return nil
+	}
+	return cobra.MinimumNArgs(1)(cmd, args)
+}
+
+// loadLibraries returns the libraries used by the packages, using the flags shared between subcommands.
+// With --proxy_dir, the libraries are the modules required by the main module
+// instead, whose license files are extracted to a temporary directory.
+func loadLibraries(ctx context.Context, classifier licenses.Classifier, packages []string) ([]*licenses.Library, error) {
+	opts := licenses.Options{
+		IncludeTests: includeTests,
+		IgnoredPaths: ignore,
+		ScanAssets:   scanAssets,
+	}
+	switch verifySums {
+	case "off":
+		opts.VerifySums = licenses.SkipSumVerification
+	case "warn":
+		opts.VerifySums = licenses.WarnOnSumMismatch
+	case "fail":
+		opts.VerifySums = licenses.FailOnSumMismatch
+	default:
+		return nil, fmt.Errorf("invalid --verify_sums %q, want off, warn or fail", verifySums)
+	}
+	if proxyDir == "" {
+		return licenses.LoadLibraries(ctx, classifier, opts, packages...)
+	}
+	if includeTests || scanAssets {
+		klog.Warning("--include_tests and --scan_assets are ignored with --proxy_dir")
+	}
+	licenseDir, err := os.MkdirTemp("", "go-licenses-proxy")
+	if err != nil {
+		return nil, err
+	}
+	tempDirs = append(tempDirs, licenseDir)
+	return licenses.ProxyLibraries(ctx, classifier, opts, proxyDir, licenseDir)
+}
+
 // Unvendor removes the "*/vendor/" prefix from the given import path, if present.
 func unvendor(importPath string) string {
 	if vendorerAndVendoree := strings.SplitN(importPath, "/vendor/", 2); len(vendorerAndVendoree) == 2 {
@@ -0,0 +1,58 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+)
+
+func TestPackageArgs(t *testing.T) {
+	for _, test := range []struct {
+		desc     string
+		proxyDir string
+		args     []string
+		wantErr  string
+	}{
+		{
+			desc: "Packages",
+			args: []string{"./..."},
+		},
+		{
+			desc:    "No package",
+			wantErr: "requires at least 1 arg(s), only received 0",
+		},
+		{
+			desc:     "Proxy dir",
+			proxyDir: "/mnt/goproxy",
+		},
+		{
+			desc:     "Packages with proxy dir",
+			proxyDir: "/mnt/goproxy",
+			args:     []string{"./..."},
+			wantErr:  `package arguments ["./..."] can't be used with --proxy_dir, which reads all the modules required by the main module's go.mod`,
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			oldProxyDir := proxyDir
+			defer func() { proxyDir = oldProxyDir }()
+			proxyDir = test.proxyDir
+
+			err := packageArgs(reportCmd, test.args)
+			if test.wantErr == "" && err != nil || test.wantErr != "" && (err == nil || err.Error() != test.wantErr) {
+				t.Errorf("packageArgs(%q) = %v, want %q", test.args, err, test.wantErr)
+			}
+		})
+	}
+}
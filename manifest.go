@@ -0,0 +1,130 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"slices"
+	"sort"
+
+	"github.com/google/go-licenses/v2/licenses"
+)
+
+// manifestPath is the path of the manifest, relative to the save path.
+const manifestPath = "go-licenses-manifest.json"
+
+// saveManifest describes everything written by the save command, so that the
+// saved files can be audited later.
+type saveManifest struct {
+	Libraries []manifestLibrary `json:"libraries"`
+}
+
+// manifestLibrary describes a library and the files saved for it.
+type manifestLibrary struct {
+	Name            string                          `json:"name"`
+	Version         string                          `json:"version,omitempty"`
+	Licenses        []manifestLicense               `json:"licenses"`
+	Restrictiveness licenses.LicenseRestrictiveness `json:"restrictiveness"`
+	Files           []manifestFile                  `json:"files"`
+	// Original is the module replaced by the library's module, if any, and
+	// Replacement is the library's module then, with the directory as path for a
+	// local one.
+	Original    *manifestModule `json:"original,omitempty"`
+	Replacement *manifestModule `json:"replacement,omitempty"`
+	// Notices are the paths of the saved files that are copyright notices applying to the library.
+	Notices []string `json:"notices,omitempty"`
+}
+
+type manifestModule struct {
+	Path    string `json:"path"`
+	Version string `json:"version,omitempty"`
+}
+
+type manifestLicense struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type manifestFile struct {
+	// Path is relative to the save path.
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// add records lib and the files saved for it, at the given paths in files.
+// notices are the source paths of the copyright notices applying to lib.
+func (m *saveManifest) add(lib *licenses.Library, restrictiveness licenses.LicenseRestrictiveness, files saveFiles, saved, notices []string) error {
+	entry := manifestLibrary{
+		Name:            lib.Name(),
+		Version:         lib.Version(),
+		Licenses:        []manifestLicense{},
+		Restrictiveness: restrictiveness,
+		Files:           []manifestFile{},
+	}
+	if m := lib.Module(); m != nil && m.Original != nil {
+		entry.Original = &manifestModule{Path: m.Original.Path, Version: m.Original.Version}
+		entry.Replacement = &manifestModule{Path: m.Path, Version: m.Version}
+	}
+	for _, license := range lib.Licenses {
+		entry.Licenses = append(entry.Licenses, manifestLicense{
+			Name: license.Name,
+			Type: license.Type.String(),
+		})
+	}
+	isNotice := map[string]bool{}
+	for _, notice := range notices {
+		isNotice[notice] = true
+	}
+	sort.Strings(saved)
+	for _, p := range slices.Compact(saved) {
+		sum, err := fileSHA256(files[p])
+		if err != nil {
+			return err
+		}
+		entry.Files = append(entry.Files, manifestFile{Path: p, SHA256: sum})
+		if isNotice[files[p].src] {
+			entry.Notices = append(entry.Notices, p)
+		}
+	}
+	m.Libraries = append(m.Libraries, entry)
+	return nil
+}
+
+// addTo adds the manifest itself to files.
+func (m *saveManifest) addTo(files saveFiles) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	files[manifestPath] = saveFile{data: append(data, '\n')}
+	return nil
+}
+
+// fileSHA256 returns the hex-encoded SHA-256 hash of the content of f.
+func fileSHA256(f saveFile) (string, error) {
+	r, err := f.open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
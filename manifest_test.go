@@ -0,0 +1,57 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-licenses/v2/licenses"
+)
+
+func TestSaveManifest(t *testing.T) {
+	files := saveFiles{
+		"example.com/lib/LICENSE": {data: []byte("hello\n")},
+	}
+	lib := &licenses.Library{
+		Packages: []string{"example.com/lib"},
+		Licenses: []licenses.License{{Name: "MIT", Type: licenses.Notice}},
+	}
+	var manifest saveManifest
+	if err := manifest.add(lib, licenses.RestrictionsShareLicense, files, []string{"example.com/lib/LICENSE"}, nil); err != nil {
+		t.Fatalf("add() = %v, want nil", err)
+	}
+	want := saveManifest{
+		Libraries: []manifestLibrary{{
+			Name:            "example.com/lib",
+			Licenses:        []manifestLicense{{Name: "MIT", Type: "notice"}},
+			Restrictiveness: licenses.RestrictionsShareLicense,
+			Files: []manifestFile{{
+				Path:   "example.com/lib/LICENSE",
+				SHA256: "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+			}},
+		}},
+	}
+	if diff := cmp.Diff(want, manifest); diff != "" {
+		t.Errorf("manifest diff (-want +got): %s", diff)
+	}
+
+	if err := manifest.addTo(files); err != nil {
+		t.Fatalf("addTo() = %v, want nil", err)
+	}
+	if _, ok := files[manifestPath]; !ok {
+		t.Errorf("addTo() did not add %s", manifestPath)
+	}
+}
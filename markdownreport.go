@@ -0,0 +1,118 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/google/go-licenses/v2/licenses"
+)
+
+var markdownReportTemplate = template.Must(template.New("").Funcs(template.FuncMap{
+	"md":   markdownEscaper.Replace,
+	"join": join,
+	// Leading spaces are kept, as licenses like Apache-2.0 start indented.
+	"trimNewlines": func(s string) string { return strings.Trim(s, "\n") },
+}).Parse(`# Open source licenses
+
+This software uses the following {{len .Libraries}} open source libraries.
+
+## Summary
+
+| License type | Libraries | Licenses |
+| --- | ---: | --- |
+{{- range .Types}}
+| {{.Type}} | {{.Libraries}} | {{join ", " .Licenses | md}} |
+{{- end}}
+
+## Libraries
+
+| Library | Version | License | URL |
+| --- | --- | --- | --- |
+{{- range .Libraries}}
+| {{md .Name}} | {{md .Version}} | {{if .LicenseNames}}{{join ", " .LicenseNames | md}}{{else}}Unknown{{end}} | {{if ne .LicenseURL "Unknown"}}<{{.LicenseURL}}>{{else}}Unknown{{end}} |
+{{- end}}
+{{- if .LicenseTexts}}
+
+## License texts
+{{- range .Libraries}}
+{{- $name := .Name}}
+{{- with .LicenseText}}
+
+### {{md $name}}
+
+` + "````" + `
+{{trimNewlines .}}
+` + "````" + `
+{{- end}}
+{{- end}}
+{{- end}}
+`))
+
+// markdownLicenseType summarizes the libraries with a license type in the
+// Markdown report.
+type markdownLicenseType struct {
+	Type string
+	// Libraries is the number of libraries with a license of this type.
+	Libraries int
+	// Licenses are the names of the licenses of this type.
+	Licenses []string
+}
+
+// reportMarkdown writes the libraries as a Markdown document: a summary table by
+// license type, a table of the libraries and, when licenseTexts is true, an
+// appendix with the text of their licenses.
+func reportMarkdown(w io.Writer, libs []libraryData, licenseTexts bool) error {
+	types := map[string]*markdownLicenseType{}
+	for _, lib := range libs {
+		libTypes := lib.LicenseTypes
+		if len(libTypes) == 0 {
+			libTypes = []string{licenses.Unknown.String()}
+		}
+		counted := map[string]bool{}
+		for i, t := range libTypes {
+			if types[t] == nil {
+				types[t] = &markdownLicenseType{Type: t}
+			}
+			if !counted[t] {
+				counted[t] = true
+				types[t].Libraries++
+			}
+			if i < len(lib.LicenseNames) && !slices.Contains(types[t].Licenses, lib.LicenseNames[i]) {
+				types[t].Licenses = append(types[t].Licenses, lib.LicenseNames[i])
+			}
+		}
+	}
+	data := struct {
+		Libraries    []libraryData
+		Types        []*markdownLicenseType
+		LicenseTexts bool
+	}{
+		Libraries:    libs,
+		LicenseTexts: licenseTexts,
+	}
+	for _, t := range types {
+		sort.Strings(t.Licenses)
+		data.Types = append(data.Types, t)
+	}
+	sort.Slice(data.Types, func(i, j int) bool {
+		return data.Types[i].Type < data.Types[j].Type
+	})
+	return markdownReportTemplate.Execute(w, data)
+}
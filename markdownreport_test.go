@@ -0,0 +1,111 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReportMarkdown(t *testing.T) {
+	licensePath := filepath.Join(t.TempDir(), "LICENSE")
+	if err := os.WriteFile(licensePath, []byte("MIT License\n\nCopyright (c) 2020 Foo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	libs := []libraryData{
+		{
+			Name:         "example.com/dual_license",
+			Version:      "v1.0.0",
+			LicensePath:  licensePath,
+			LicenseURL:   "https://example.com/dual/LICENSE",
+			LicenseNames: []string{"MIT", "Apache-2.0"},
+			LicenseTypes: []string{"notice", "notice"},
+		},
+		{
+			Name:         "example.com/gpl",
+			Version:      "v2.0.0",
+			LicensePath:  UNKNOWN,
+			LicenseURL:   UNKNOWN,
+			LicenseNames: []string{"GPL-2.0"},
+			LicenseTypes: []string{"restricted"},
+		},
+		{
+			Name:        "example.com/none",
+			Version:     UNKNOWN,
+			LicensePath: UNKNOWN,
+			LicenseURL:  UNKNOWN,
+		},
+	}
+	wantTables := `# Open source licenses
+
+This software uses the following 3 open source libraries.
+
+## Summary
+
+| License type | Libraries | Licenses |
+| --- | ---: | --- |
+| notice | 1 | Apache-2.0, MIT |
+| restricted | 1 | GPL-2.0 |
+| unknown | 1 |  |
+
+## Libraries
+
+| Library | Version | License | URL |
+| --- | --- | --- | --- |
+| example.com/dual\_license | v1.0.0 | MIT, Apache-2.0 | <https://example.com/dual/LICENSE> |
+| example.com/gpl | v2.0.0 | GPL-2.0 | Unknown |
+| example.com/none | Unknown | Unknown | Unknown |
+`
+	tests := []struct {
+		name         string
+		licenseTexts bool
+		want         string
+	}{
+		{
+			name: "tables",
+			want: wantTables,
+		},
+		{
+			name:         "license texts",
+			licenseTexts: true,
+			want: wantTables + `
+## License texts
+
+### example.com/dual\_license
+
+` + "````" + `
+MIT License
+
+Copyright (c) 2020 Foo
+` + "````" + `
+`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got strings.Builder
+			if err := reportMarkdown(&got, libs, test.licenseTexts); err != nil {
+				t.Fatalf("reportMarkdown() = %v", err)
+			}
+			if diff := cmp.Diff(test.want, got.String()); diff != "" {
+				t.Errorf("reportMarkdown() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -17,8 +17,15 @@ package main
 import (
 	"context"
 	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"text/template"
 	"time"
 
@@ -39,58 +46,139 @@ var (
 		Use:   "report <package> [package...]",
 		Short: reportHelp,
 		Long:  reportHelp + packageHelp,
-		Args:  cobra.MinimumNArgs(1),
+		Args:  packageArgs,
 		RunE:  reportMain,
 	}
 
 	templateFile string
+	reportFormat string
+	licenseTexts bool
+	outputFlags  []string
+	// csvColumnNames are the names of the columns of the CSV report, see csvColumns.
+	csvColumnNames []string
+	csvHeader      bool
+	verifyURLs     bool
+	fallbackURL    string
 )
 
 func init() {
 	reportCmd.Flags().StringVar(&templateFile, "template", "", "Custom Go template file to use for report")
+	reportCmd.Flags().StringVar(&reportFormat, "format", "csv", "Format of the report when no template is given: csv, json, html or markdown.")
+	reportCmd.Flags().BoolVar(&licenseTexts, "license_texts", false, "With the markdown format, append the full text of the licenses.")
+	reportCmd.Flags().StringSliceVar(&csvColumnNames, "csv_columns", defaultCSVColumns, "Columns of the CSV report, among name, version, license_url, license_name, license_type, license_path, module_path, restrictiveness, direct, depth, original_name, original_version and replacement_name.")
+	addSummaryFlag(reportCmd, "Print counts of libraries by license name, license type and restrictiveness, and of modules, instead of the report.")
+	reportCmd.Flags().BoolVar(&csvHeader, "csv_header", false, "Start the CSV report with a header row of the column names.")
+	reportCmd.Flags().StringArrayVar(&outputFlags, "output", nil, "Write the report in a format to a file, as format=path, where format is csv, json, html, markdown or template (using --template), and path is - for stdout. Can be specified multiple times to write several reports from a single analysis. Can't be used with --format.")
+	reportCmd.Flags().BoolVar(&verifyURLs, "verify_urls", false, "Check that each license URL exists, falling back to pkg.go.dev or the module proxy when it doesn't.")
+	reportCmd.Flags().StringVar(&fallbackURL, "fallback_url", "", "License URL to use when the module's repository can't be resolved: \"proxy\", \"pkgsite\", or a custom template with {module}, {version}, {module_escaped}, {version_escaped} and {file} placeholders.")
 
 	rootCmd.AddCommand(reportCmd)
 }
 
 type libraryData struct {
-	Name         string
-	Version      string
-	LicensePath  string
-	LicenseURL   string
-	LicenseNames []string
+	Name         string   `json:"name"`
+	Version      string   `json:"version"`
+	LicensePath  string   `json:"license_path"`
+	LicenseURL   string   `json:"license_url"`
+	LicenseNames []string `json:"license_names"`
+	// LicenseTypes are the types of the licenses in LicenseNames, e.g. "notice".
+	LicenseTypes []string `json:"license_types"`
+	// ModulePath is the path of the library's module, or empty when not in a module.
+	ModulePath string `json:"module_path,omitempty"`
+	// Restrictiveness is the restrictiveness of the library's licenses, as a whole.
+	Restrictiveness licenses.LicenseRestrictiveness `json:"restrictiveness"`
+	// OriginalName and OriginalVersion are the module path and version replaced
+	// by a replace directive, if any, and ReplacementName is the path of the
+	// replacement module, or its directory for a local one. Name is still the
+	// import path, under the original module path, while Version is the version
+	// of the replacement, unknown for a local directory.
+	OriginalName    string `json:"original_name,omitempty"`
+	OriginalVersion string `json:"original_version,omitempty"`
+	ReplacementName string `json:"replacement_name,omitempty"`
+	// Direct is true for direct dependencies of the modules of the reported
+	// packages. Depth is 0 for libraries in those modules, 1 for direct
+	// dependencies and more for indirect ones.
+	Direct bool `json:"direct"`
+	Depth  int  `json:"depth"`
+
+	lib *licenses.Library
 }
 
 type libraryDataFlat struct {
-	Name        string
-	Version     string
-	LicensePath string
-	LicenseURL  string
-	LicenseName string
+	Name            string
+	Version         string
+	LicensePath     string
+	LicenseURL      string
+	LicenseName     string
+	LicenseType     string
+	ModulePath      string
+	Restrictiveness licenses.LicenseRestrictiveness
+	OriginalName    string
+	OriginalVersion string
+	ReplacementName string
+	Direct          bool
+	Depth           int
+
+	lib *licenses.Library
 }
 
 // LicenseText reads and returns the contents of LicensePath, if set
 // or an empty string if not.
 func (lib libraryDataFlat) LicenseText() (string, error) {
-	if lib.LicensePath == "" {
+	return licenseText(lib.LicensePath)
+}
+
+// LicenseText reads and returns the contents of LicensePath, if set
+// or an empty string if not.
+func (lib libraryData) LicenseText() (string, error) {
+	return licenseText(lib.LicensePath)
+}
+
+func licenseText(path string) (string, error) {
+	if path == "" || path == UNKNOWN {
 		return "", nil
 	}
-	data, err := os.ReadFile(lib.LicensePath)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return "", err
 	}
 	return string(data), nil
 }
 
-func reportMain(_ *cobra.Command, args []string) error {
+func reportMain(cmd *cobra.Command, args []string) error {
+	// The deprecated csv command has no command-line flags of its own.
+	formatSet := cmd != nil && cmd.Flags().Changed("format")
+	if err := checkSummaryFormat(); err != nil {
+		return err
+	}
+	if summaryFormat != "" && (formatSet || templateFile != "" || len(outputFlags) > 0) {
+		return errors.New("--summary can't be used with --format, --template or --output")
+	}
+	outputs, err := reportOutputs(formatSet)
+	if err != nil {
+		return err
+	}
+	if err := checkCSVColumns(); err != nil {
+		return err
+	}
+
+	fallbackTemplate, err := fallbackURLTemplate(fallbackURL)
+	if err != nil {
+		return err
+	}
+
 	classifier, err := licenses.NewClassifier()
 	if err != nil {
 		return err
 	}
 
-	libs, err := licenses.Libraries(context.Background(), classifier, includeTests, ignore, args...)
+	libs, err := loadLibraries(context.Background(), classifier, args)
 	if err != nil {
 		return err
 	}
+	if summaryFormat != "" {
+		return summarize(libs).write(os.Stdout, summaryFormat)
+	}
 
 	reportData := make([]libraryData, len(libs))
 	client := source.NewClient(time.Second * 20)
@@ -105,23 +193,45 @@ func reportMain(_ *cobra.Command, args []string) error {
 			LicensePath:  UNKNOWN,
 			LicenseURL:   UNKNOWN,
 			LicenseNames: nil,
+			ModulePath:   lib.ModulePath(),
+			Direct:       lib.IsDirect(),
+			Depth:        lib.Depth(),
+			lib:          lib,
 		}
 
 		if version := lib.Version(); version != "" {
 			reportData[idx].Version = version
 		}
 
+		if m := lib.Module(); m != nil && m.Original != nil {
+			reportData[idx].OriginalName = m.Original.Path
+			reportData[idx].OriginalVersion = m.Original.Version
+			reportData[idx].ReplacementName = m.Path
+		}
+
 		if lib.LicenseFile != "" {
 			reportData[idx].LicensePath = lib.LicenseFile
 		}
 
+		var licenseTypes []licenses.Type
 		for _, license := range lib.Licenses {
 			reportData[idx].LicenseNames = append(reportData[idx].LicenseNames, license.Name)
+			reportData[idx].LicenseTypes = append(reportData[idx].LicenseTypes, license.Type.String())
+			licenseTypes = append(licenseTypes, license.Type)
 		}
+		reportData[idx].Restrictiveness = licenses.LicenseTypeRestrictiveness(licenseTypes...)
 
 		if lib.LicenseFile != "" {
 			group.Go(func() error {
-				url, err := lib.FileURL(gctx, client, lib.LicenseFile)
+				fileURL := lib.FileURL
+				if verifyURLs {
+					fileURL = lib.VerifiedFileURL
+				}
+				url, err := fileURL(gctx, client, lib.LicenseFile)
+				if err != nil && fallbackTemplate != "" {
+					klog.Warningf("Error discovering license URL, using fallback URL: %s", err)
+					url, err = expandFallbackURL(lib, fallbackTemplate, lib.LicenseFile)
+				}
 				if err == nil {
 					reportData[idx].LicenseURL = url
 				} else {
@@ -129,6 +239,12 @@ func reportMain(_ *cobra.Command, args []string) error {
 				}
 				return nil
 			})
+		} else if fallbackTemplate != "" {
+			if url, err := expandFallbackURL(lib, fallbackTemplate, ""); err == nil {
+				reportData[idx].LicenseURL = url
+			} else {
+				klog.Warningf("Error discovering license URL: %s", err)
+			}
 		}
 	}
 
@@ -148,36 +264,220 @@ func reportMain(_ *cobra.Command, args []string) error {
 				klog.Errorf("Error identifying license for %q: %v", lib.Name, fmt.Errorf("no license found"))
 			}
 			reportDataFlat = append(reportDataFlat, libraryDataFlat{
-				Name:        lib.Name,
-				Version:     lib.Version,
-				LicensePath: lib.LicensePath,
-				LicenseURL:  lib.LicenseURL,
-				LicenseName: UNKNOWN,
+				Name:            lib.Name,
+				Version:         lib.Version,
+				LicensePath:     lib.LicensePath,
+				LicenseURL:      lib.LicenseURL,
+				LicenseName:     UNKNOWN,
+				LicenseType:     licenses.Unknown.String(),
+				ModulePath:      lib.ModulePath,
+				Restrictiveness: lib.Restrictiveness,
+				OriginalName:    lib.OriginalName,
+				OriginalVersion: lib.OriginalVersion,
+				ReplacementName: lib.ReplacementName,
+				Direct:          lib.Direct,
+				Depth:           lib.Depth,
+				lib:             lib.lib,
 			})
 		} else {
-			for _, licenseName := range lib.LicenseNames {
+			for i, licenseName := range lib.LicenseNames {
 				reportDataFlat = append(reportDataFlat, libraryDataFlat{
-					Name:        lib.Name,
-					Version:     lib.Version,
-					LicensePath: lib.LicensePath,
-					LicenseURL:  lib.LicenseURL,
-					LicenseName: licenseName,
+					Name:            lib.Name,
+					Version:         lib.Version,
+					LicensePath:     lib.LicensePath,
+					LicenseURL:      lib.LicenseURL,
+					LicenseName:     licenseName,
+					LicenseType:     lib.LicenseTypes[i],
+					ModulePath:      lib.ModulePath,
+					Restrictiveness: lib.Restrictiveness,
+					OriginalName:    lib.OriginalName,
+					OriginalVersion: lib.OriginalVersion,
+					ReplacementName: lib.ReplacementName,
+					Direct:          lib.Direct,
+					Depth:           lib.Depth,
+					lib:             lib.lib,
 				})
 			}
 		}
 	}
 
-	if templateFile == "" {
-		return reportCSV(reportDataFlat)
+	for _, output := range outputs {
+		if err := output.write(reportData, reportDataFlat); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reportFormats are the formats of reports. The template format uses the
+// template in --template.
+var reportFormats = []string{"csv", "json", "html", "markdown", "template"}
+
+// reportOutput is a report format and the path of the file it's written to.
+type reportOutput struct {
+	format string
+	// path is "-" for stdout.
+	path string
+}
+
+// reportOutputs returns the outputs selected by the --output, --format and
+// --template flags. formatSet tells whether --format was set explicitly.
+func reportOutputs(formatSet bool) ([]reportOutput, error) {
+	var outputs []reportOutput
+	if len(outputFlags) == 0 {
+		if !slices.Contains(reportFormats, reportFormat) || reportFormat == "template" {
+			return nil, fmt.Errorf("invalid --format %q: want csv, json, html or markdown", reportFormat)
+		}
+		format := reportFormat
+		if templateFile != "" {
+			if reportFormat != "csv" {
+				return nil, fmt.Errorf("--template and --format=%s can't be used at the same time", reportFormat)
+			}
+			format = "template"
+		}
+		outputs = []reportOutput{{format: format, path: "-"}}
 	} else {
-		return reportTemplate(reportDataFlat)
+		if formatSet {
+			return nil, errors.New("--format and --output can't be used at the same time")
+		}
+		paths := map[string]bool{}
+		for _, flag := range outputFlags {
+			format, path, ok := strings.Cut(flag, "=")
+			if !ok || path == "" {
+				return nil, fmt.Errorf("invalid --output %q: want format=path", flag)
+			}
+			if !slices.Contains(reportFormats, format) {
+				return nil, fmt.Errorf("invalid --output %q: format must be one of %s", flag, strings.Join(reportFormats, ", "))
+			}
+			if paths[path] {
+				return nil, fmt.Errorf("invalid --output %q: %s is already an output", flag, path)
+			}
+			paths[path] = true
+			outputs = append(outputs, reportOutput{format: format, path: path})
+		}
+	}
+
+	hasFormat := func(format string) bool {
+		return slices.ContainsFunc(outputs, func(o reportOutput) bool { return o.format == format })
+	}
+	if hasFormat("template") != (templateFile != "") {
+		return nil, errors.New("--template must be used with a template=path output, and the other way around")
+	}
+	if licenseTexts && !hasFormat("markdown") {
+		return nil, errors.New("--license_texts can only be used with the markdown format")
+	}
+	return outputs, nil
+}
+
+// write writes the report to the output's path.
+func (o reportOutput) write(libs []libraryData, flat []libraryDataFlat) error {
+	if o.path == "-" {
+		return writeReport(os.Stdout, o.format, libs, flat)
+	}
+	f, err := os.Create(o.path)
+	if err != nil {
+		return err
+	}
+	if err := writeReport(f, o.format, libs, flat); err != nil {
+		f.Close()
+		return fmt.Errorf("writing %s report to %s: %w", o.format, o.path, err)
+	}
+	return f.Close()
+}
+
+// writeReport writes the report in format to w.
+func writeReport(w io.Writer, format string, libs []libraryData, flat []libraryDataFlat) error {
+	switch format {
+	case "json":
+		return reportJSON(w, libs)
+	case "html":
+		return reportHTML(w, libs)
+	case "markdown":
+		return reportMarkdown(w, libs, licenseTexts)
+	case "template":
+		return reportTemplate(w, libs, flat)
+	}
+	return reportCSV(w, flat)
+}
+
+// fallbackURLTemplate returns the URL template selected by the --fallback_url flag.
+func fallbackURLTemplate(fallback string) (string, error) {
+	switch fallback {
+	case "":
+		return "", nil
+	case "proxy":
+		return licenses.ProxyZipURLTemplate, nil
+	case "pkgsite":
+		return licenses.PkgGoDevURLTemplate, nil
+	}
+	if !strings.Contains(fallback, "{") {
+		return "", fmt.Errorf("invalid --fallback_url %q: want \"proxy\", \"pkgsite\" or a template with placeholders", fallback)
+	}
+	return fallback, nil
+}
+
+// expandFallbackURL returns the URL of filePath in lib built from the --fallback_url
+// template tmpl. Modules without a version, like the main module, can't fill the
+// template, so they link to their unversioned page on pkg.go.dev instead.
+func expandFallbackURL(lib *licenses.Library, tmpl, filePath string) (string, error) {
+	url, err := lib.TemplateFileURL(tmpl, filePath)
+	if err != nil && lib.Version() == "" {
+		if urls := lib.FallbackURLs(); len(urls) > 0 {
+			return urls[0], nil
+		}
+	}
+	return url, err
+}
+
+// csvColumns are the columns of the CSV report, by name.
+var csvColumns = map[string]func(libraryDataFlat) string{
+	"name":             func(lib libraryDataFlat) string { return lib.Name },
+	"version":          func(lib libraryDataFlat) string { return lib.Version },
+	"license_url":      func(lib libraryDataFlat) string { return lib.LicenseURL },
+	"license_name":     func(lib libraryDataFlat) string { return lib.LicenseName },
+	"license_type":     func(lib libraryDataFlat) string { return lib.LicenseType },
+	"license_path":     func(lib libraryDataFlat) string { return lib.LicensePath },
+	"module_path":      func(lib libraryDataFlat) string { return lib.ModulePath },
+	"restrictiveness":  func(lib libraryDataFlat) string { return string(lib.Restrictiveness) },
+	"direct":           func(lib libraryDataFlat) string { return strconv.FormatBool(lib.Direct) },
+	"depth":            func(lib libraryDataFlat) string { return strconv.Itoa(lib.Depth) },
+	"original_name":    func(lib libraryDataFlat) string { return lib.OriginalName },
+	"original_version": func(lib libraryDataFlat) string { return lib.OriginalVersion },
+	"replacement_name": func(lib libraryDataFlat) string { return lib.ReplacementName },
+}
+
+// defaultCSVColumns are the columns of the CSV report when --csv_columns isn't set.
+var defaultCSVColumns = []string{"name", "license_url", "license_name"}
+
+// checkCSVColumns returns an error if a column in --csv_columns doesn't exist.
+func checkCSVColumns() error {
+	if len(csvColumnNames) == 0 {
+		return errors.New("--csv_columns can't be empty")
 	}
+	for _, name := range csvColumnNames {
+		if _, ok := csvColumns[name]; !ok {
+			names := slices.Sorted(maps.Keys(csvColumns))
+			return fmt.Errorf("invalid --csv_columns %q: want some of %s", name, strings.Join(names, ", "))
+		}
+	}
+	return nil
 }
 
-func reportCSV(libs []libraryDataFlat) error {
-	writer := csv.NewWriter(os.Stdout)
+// reportCSV writes the columns in --csv_columns of each library and license
+// name, after a header row with the column names if --csv_header is set.
+func reportCSV(w io.Writer, libs []libraryDataFlat) error {
+	writer := csv.NewWriter(w)
+	if csvHeader {
+		if err := writer.Write(csvColumnNames); err != nil {
+			return err
+		}
+	}
 	for _, lib := range libs {
-		if err := writer.Write([]string{lib.Name, lib.LicenseURL, lib.LicenseName}); err != nil {
+		record := make([]string, len(csvColumnNames))
+		for i, name := range csvColumnNames {
+			record[i] = csvColumns[name](lib)
+		}
+		if err := writer.Write(record); err != nil {
 			return err
 		}
 	}
@@ -185,14 +485,24 @@ func reportCSV(libs []libraryDataFlat) error {
 	return writer.Error()
 }
 
-func reportTemplate(libs []libraryDataFlat) error {
+// reportJSON prints the libraries as a JSON array, with all the license names
+// of a library in a single entry.
+func reportJSON(w io.Writer, libs []libraryData) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(libs)
+}
+
+// reportTemplate executes the template in templateFile with the flattened data,
+// one entry per license name. The functions of templateFuncs are available.
+func reportTemplate(w io.Writer, libs []libraryData, flat []libraryDataFlat) error {
 	templateBytes, err := os.ReadFile(templateFile)
 	if err != nil {
 		return err
 	}
-	tmpl, err := template.New("").Parse(string(templateBytes))
+	tmpl, err := template.New("").Funcs(templateFuncs(libs)).Parse(string(templateBytes))
 	if err != nil {
 		return err
 	}
-	return tmpl.Execute(os.Stdout, libs)
+	return tmpl.Execute(w, flat)
 }
@@ -0,0 +1,265 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReportOutputs(t *testing.T) {
+	for _, test := range []struct {
+		desc         string
+		format       string
+		formatSet    bool
+		template     string
+		licenseTexts bool
+		outputs      []string
+		want         []reportOutput
+		wantErr      string
+	}{
+		{
+			desc:   "Defaults to csv on stdout",
+			format: "csv",
+			want:   []reportOutput{{format: "csv", path: "-"}},
+		},
+		{
+			desc:      "Format",
+			format:    "html",
+			formatSet: true,
+			want:      []reportOutput{{format: "html", path: "-"}},
+		},
+		{
+			desc:     "Template",
+			format:   "csv",
+			template: "licenses.tpl",
+			want:     []reportOutput{{format: "template", path: "-"}},
+		},
+		{
+			desc:      "Template and format",
+			format:    "json",
+			formatSet: true,
+			template:  "licenses.tpl",
+			wantErr:   "--template and --format=json can't be used at the same time",
+		},
+		{
+			desc:      "Invalid format",
+			format:    "template",
+			formatSet: true,
+			wantErr:   `invalid --format "template": want csv, json, html or markdown`,
+		},
+		{
+			desc:         "Outputs",
+			format:       "csv",
+			template:     "licenses.tpl",
+			licenseTexts: true,
+			outputs:      []string{"csv=licenses.csv", "markdown=-", "template=out/licenses.txt"},
+			want: []reportOutput{
+				{format: "csv", path: "licenses.csv"},
+				{format: "markdown", path: "-"},
+				{format: "template", path: "out/licenses.txt"},
+			},
+		},
+		{
+			desc:      "Outputs and format",
+			format:    "json",
+			formatSet: true,
+			outputs:   []string{"csv=licenses.csv"},
+			wantErr:   "--format and --output can't be used at the same time",
+		},
+		{
+			desc:    "Output without path",
+			format:  "csv",
+			outputs: []string{"csv"},
+			wantErr: `invalid --output "csv": want format=path`,
+		},
+		{
+			desc:    "Output with invalid format",
+			format:  "csv",
+			outputs: []string{"pdf=licenses.pdf"},
+			wantErr: `invalid --output "pdf=licenses.pdf": format must be one of csv, json, html, markdown, template`,
+		},
+		{
+			desc:    "Same path twice",
+			format:  "csv",
+			outputs: []string{"csv=-", "json=-"},
+			wantErr: `invalid --output "json=-": - is already an output`,
+		},
+		{
+			desc:    "Template output without template",
+			format:  "csv",
+			outputs: []string{"template=licenses.txt"},
+			wantErr: "--template must be used with a template=path output, and the other way around",
+		},
+		{
+			desc:         "License texts without markdown",
+			format:       "csv",
+			licenseTexts: true,
+			outputs:      []string{"html=licenses.html"},
+			wantErr:      "--license_texts can only be used with the markdown format",
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			oldFormat, oldTemplate, oldLicenseTexts, oldOutputs := reportFormat, templateFile, licenseTexts, outputFlags
+			defer func() {
+				reportFormat, templateFile, licenseTexts, outputFlags = oldFormat, oldTemplate, oldLicenseTexts, oldOutputs
+			}()
+			reportFormat, templateFile, licenseTexts, outputFlags = test.format, test.template, test.licenseTexts, test.outputs
+
+			got, err := reportOutputs(test.formatSet)
+			if test.wantErr != "" {
+				if err == nil || err.Error() != test.wantErr {
+					t.Fatalf("reportOutputs() = (_, %v), want (_, %q)", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("reportOutputs() = (_, %v), want (_, nil)", err)
+			}
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(reportOutput{})); diff != "" {
+				t.Errorf("reportOutputs() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReportOutputWrite(t *testing.T) {
+	libs := []libraryData{{
+		Name:         "example.com/lib",
+		Version:      "v1.0.0",
+		LicensePath:  UNKNOWN,
+		LicenseURL:   "https://example.com/lib/LICENSE",
+		LicenseNames: []string{"MIT"},
+		LicenseTypes: []string{"notice"},
+	}}
+	flat := []libraryDataFlat{{
+		Name:        "example.com/lib",
+		Version:     "v1.0.0",
+		LicensePath: UNKNOWN,
+		LicenseURL:  "https://example.com/lib/LICENSE",
+		LicenseName: "MIT",
+		LicenseType: "notice",
+	}}
+	dir := t.TempDir()
+	for _, test := range []struct {
+		format string
+		want   string
+	}{
+		{format: "csv", want: "example.com/lib,https://example.com/lib/LICENSE,MIT\n"},
+		{format: "json", want: `"license_names": [` + "\n" + `      "MIT"`},
+		{format: "html", want: `<a href="https://example.com/lib/LICENSE">example.com/lib</a>`},
+		{format: "markdown", want: "| example.com/lib | v1.0.0 | MIT | <https://example.com/lib/LICENSE> |"},
+	} {
+		t.Run(test.format, func(t *testing.T) {
+			path := filepath.Join(dir, "licenses."+test.format)
+			if err := (reportOutput{format: test.format, path: path}).write(libs, flat); err != nil {
+				t.Fatalf("write() = %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(got), test.want) {
+				t.Errorf("%s report is\n%s\nwant it to contain\n%s", test.format, got, test.want)
+			}
+		})
+	}
+}
+
+func TestReportCSV(t *testing.T) {
+	libs := []libraryDataFlat{
+		{
+			Name:            "example.com/lib",
+			Version:         "v1.0.0",
+			LicensePath:     "/go/pkg/mod/example.com/lib@v1.0.0/LICENSE",
+			LicenseURL:      "https://example.com/lib/LICENSE",
+			LicenseName:     "MIT",
+			LicenseType:     "notice",
+			ModulePath:      "example.com/lib",
+			Restrictiveness: "ShareLicense",
+			Direct:          true,
+			Depth:           1,
+		},
+		{
+			Name:            "example.com/upstream/sub",
+			Version:         "v1.1.0",
+			LicensePath:     UNKNOWN,
+			LicenseURL:      UNKNOWN,
+			LicenseName:     UNKNOWN,
+			LicenseType:     "unknown",
+			ModulePath:      "example.com/fork",
+			Restrictiveness: "Unknown",
+			Depth:           2,
+			OriginalName:    "example.com/upstream",
+			OriginalVersion: "v1.0.0",
+			ReplacementName: "example.com/fork",
+		},
+	}
+	for _, test := range []struct {
+		desc    string
+		columns []string
+		header  bool
+		want    string
+		wantErr string
+	}{
+		{
+			desc:    "Default columns",
+			columns: defaultCSVColumns,
+			want: "example.com/lib,https://example.com/lib/LICENSE,MIT\n" +
+				"example.com/upstream/sub,Unknown,Unknown\n",
+		},
+		{
+			desc:    "All columns with header",
+			columns: []string{"name", "version", "license_type", "license_path", "module_path", "restrictiveness", "direct", "depth", "original_name", "original_version", "replacement_name"},
+			header:  true,
+			want: "name,version,license_type,license_path,module_path,restrictiveness,direct,depth,original_name,original_version,replacement_name\n" +
+				"example.com/lib,v1.0.0,notice,/go/pkg/mod/example.com/lib@v1.0.0/LICENSE,example.com/lib,ShareLicense,true,1,,,\n" +
+				"example.com/upstream/sub,v1.1.0,unknown,Unknown,example.com/fork,Unknown,false,2,example.com/upstream,v1.0.0,example.com/fork\n",
+		},
+		{
+			desc:    "Unknown column",
+			columns: []string{"name", "sha"},
+			wantErr: `invalid --csv_columns "sha": want some of depth, direct, license_name, license_path, license_type, license_url, module_path, name, original_name, original_version, replacement_name, restrictiveness, version`,
+		},
+		{
+			desc:    "No columns",
+			wantErr: "--csv_columns can't be empty",
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			oldColumns, oldHeader := csvColumnNames, csvHeader
+			defer func() { csvColumnNames, csvHeader = oldColumns, oldHeader }()
+			csvColumnNames, csvHeader = test.columns, test.header
+
+			if err := checkCSVColumns(); test.wantErr != "" || err != nil {
+				if err == nil || err.Error() != test.wantErr {
+					t.Fatalf("checkCSVColumns() = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			var got strings.Builder
+			if err := reportCSV(&got, libs); err != nil {
+				t.Fatalf("reportCSV() = %v", err)
+			}
+			if diff := cmp.Diff(test.want, got.String()); diff != "" {
+				t.Errorf("reportCSV() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
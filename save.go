@@ -15,11 +15,16 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/google/go-licenses/v2/licenses"
@@ -34,17 +39,35 @@ var (
 		Use:   "save <package> [package...]",
 		Short: saveHelp,
 		Long:  saveHelp + packageHelp,
-		Args:  cobra.MinimumNArgs(1),
+		Args:  packageArgs,
 		RunE:  saveMain,
 	}
 
-	noticeRegexp = regexp.MustCompile(`^NOTICE(\.(txt|md))?$`)
+	// noticeNames are the names of files saved as copyright notices, see licenses.NoticeRegexp.
+	noticeNames []string
 
 	// savePath is where the output of the command is written to.
 	savePath string
 	// overwriteSavePath controls behaviour when the directory indicated by savePath already exists.
 	// If true, the directory will be replaced. If false, the command will fail.
 	overwriteSavePath bool
+	// saveFormat is the format of the output: a directory, or an archive file.
+	saveFormat string
+	// saveModuleSource controls what is saved for libraries whose license requires sharing
+	// source code. If true, the module zip and a notice describing it are saved. If false, the
+	// directory containing the license file is copied.
+	saveModuleSource bool
+	// saveUsedPackagesOnly controls which source code is copied for libraries whose license
+	// requires sharing source code. If true, only the source files of the packages used are
+	// copied, instead of the whole directory containing the license file.
+	saveUsedPackagesOnly bool
+	// dedupeLicenses controls how license texts are saved. If empty, each library gets
+	// its own copy. Otherwise, texts are saved once by content hash and referenced
+	// from each library directory with a symlink or an index file.
+	dedupeLicenses string
+	// syncSavePath controls behaviour when the directory indicated by savePath already exists.
+	// If true, only the files that changed are written and stale files are removed.
+	syncSavePath bool
 )
 
 func init() {
@@ -56,12 +79,36 @@ func init() {
 		klog.Fatal(err)
 	}
 
+	saveCmd.Flags().StringSliceVar(&noticeNames, "notice_names", licenses.DefaultNoticeNames, "Names of files saved as copyright notices, matched ignoring case and with an optional .txt, .md or .rst extension, in the directories of the packages used and their parents up to the module root.")
 	saveCmd.Flags().BoolVar(&overwriteSavePath, "force", false, "Delete the destination directory if it already exists.")
+	saveCmd.Flags().BoolVar(&saveModuleSource, "module_source", false, "For libraries whose license requires sharing source code, save the module zip from the module cache, verified against go.sum, and a notice describing it, instead of the directory containing the license.")
+	saveCmd.Flags().BoolVar(&saveUsedPackagesOnly, "used_packages_only", false, "For libraries whose license requires sharing source code, copy only the source files of the packages used, with the license and notices, instead of the whole directory containing the license.")
+	saveCmd.Flags().StringVar(&dedupeLicenses, "dedupe_licenses", "", "Save each distinct license text once, by content hash, and reference it from library directories with a \"symlink\" or an \"index\" file.")
+	saveCmd.Flags().BoolVar(&syncSavePath, "sync", false, "Update the destination directory if it already exists: add new files, rewrite changed files and remove stale ones.")
+	saveCmd.Flags().StringVar(&saveFormat, "format", "dir", "Output format: \"dir\" writes a directory at save_path, \"tar.gz\" and \"zip\" write a reproducible archive file at save_path.")
 
 	rootCmd.AddCommand(saveCmd)
 }
 
 func saveMain(_ *cobra.Command, args []string) error {
+	write, err := saveWriter(saveFormat)
+	if err != nil {
+		return err
+	}
+	switch dedupeLicenses {
+	case "", dedupeSymlink, dedupeIndex:
+	default:
+		return fmt.Errorf("unsupported --dedupe_licenses %q: want %s or %s", dedupeLicenses, dedupeSymlink, dedupeIndex)
+	}
+	if syncSavePath {
+		if overwriteSavePath {
+			return errors.New("--sync and --force can't be used at the same time")
+		}
+		if saveFormat != "dir" {
+			return fmt.Errorf("--sync can't be used with --format=%s", saveFormat)
+		}
+		write = syncDir
+	}
 
 	if overwriteSavePath {
 		if err := os.RemoveAll(savePath); err != nil {
@@ -74,23 +121,41 @@ func saveMain(_ *cobra.Command, args []string) error {
 		return err
 	}
 
-	libs, err := licenses.Libraries(context.Background(), classifier, includeTests, ignore, args...)
+	libs, err := loadLibraries(context.Background(), classifier, args)
 	if err != nil {
 		return err
 	}
 
 	// Check that the save path doesn't exist, otherwise it'd end up with a mix of
-	// existing files and the output of this command.
-	if d, err := os.Open(savePath); err == nil {
+	// existing files and the output of this command. In sync mode, stale files are
+	// removed instead.
+	if d, err := os.Open(savePath); err == nil && !syncSavePath {
 		d.Close()
 		return fmt.Errorf("%s already exists", savePath)
+	} else if err == nil {
+		d.Close()
 	} else if !os.IsNotExist(err) {
 		return err
 	}
 
+	var goSum licenses.GoSum
+	if saveModuleSource {
+		if goSum, err = licenses.MainGoSum(context.Background()); err != nil {
+			return err
+		}
+	}
+
+	noticeRegexp := licenses.NoticeRegexp(noticeNames...)
+	files := saveFiles{}
+	manifest := saveManifest{}
 	libsWithBadLicenses := make(map[licenses.Type][]*licenses.Library)
 	for _, lib := range libs {
-		libSaveDir := filepath.Join(savePath, unvendor(lib.Name()))
+		libSaveDir := unvendor(lib.Name())
+
+		notices, err := lib.Notices(noticeRegexp)
+		if err != nil {
+			return err
+		}
 
 		licenseTypes := make([]licenses.Type, 0, len(lib.Licenses))
 		for _, license := range lib.Licenses {
@@ -99,16 +164,47 @@ func saveMain(_ *cobra.Command, args []string) error {
 
 		restrictiveness := licenses.LicenseTypeRestrictiveness(licenseTypes...)
 
+		var saved []string
 		switch restrictiveness {
 		case licenses.RestrictionsShareCode:
-			// Copy the entire source directory for the library.
+			if saveModuleSource {
+				// Copy the license and the exact source of the module, with a notice describing it.
+				if saved, err = files.addNotices(lib.LicenseFile, notices, libSaveDir); err != nil {
+					return err
+				}
+				source, err := files.addModuleSource(context.Background(), lib, goSum)
+				if err == nil {
+					saved = append(saved, source...)
+					break
+				} else if !errors.Is(err, fs.ErrNotExist) {
+					return err
+				}
+				klog.Warningf("Copying the license directory instead of the module source: %v", err)
+			}
+			if !hasSourceDir(lib) {
+				// Only the license files of modules read from zips are extracted.
+				return fmt.Errorf("can't save the source code of library %s with --proxy_dir, use --module_source to save its module zip", lib.Name())
+			}
+			if saveUsedPackagesOnly {
+				// Copy the source files of the packages used, with the license and copyright notice.
+				if saved, err = files.addPackageSrc(lib, notices, libSaveDir); err != nil {
+					return err
+				}
+				break
+			}
+			// Copy the entire source directory for the library, and notices from parent directories.
 			libDir := filepath.Dir(lib.LicenseFile)
-			if err := copySrc(libDir, libSaveDir); err != nil {
+			if saved, err = files.addSrc(libDir, libSaveDir); err != nil {
+				return err
+			}
+			licenseAndNotices, err := files.addNotices(lib.LicenseFile, notices, libSaveDir)
+			if err != nil {
 				return err
 			}
+			saved = append(saved, licenseAndNotices...)
 		case licenses.RestrictionsShareLicense:
-			// Just copy the license and copyright notice.
-			if err := copyNotices(lib.LicenseFile, libSaveDir); err != nil {
+			// Just copy the license and copyright notices.
+			if saved, err = files.addNotices(lib.LicenseFile, notices, libSaveDir); err != nil {
 				return err
 			}
 		default:
@@ -129,6 +225,24 @@ func saveMain(_ *cobra.Command, args []string) error {
 				}
 			}
 		}
+
+		if dedupeLicenses != "" && lib.LicenseFile != "" {
+			if saved, err = files.dedupe(path.Join(libSaveDir, filepath.Base(lib.LicenseFile)), dedupeLicenses, saved); err != nil {
+				return err
+			}
+		}
+
+		if err := manifest.add(lib, restrictiveness, files, saved, notices); err != nil {
+			return err
+		}
+	}
+
+	if err := manifest.addTo(files); err != nil {
+		return err
+	}
+
+	if err := write(savePath, files); err != nil {
+		return err
 	}
 
 	if len(libsWithBadLicenses) > 0 {
@@ -138,37 +252,151 @@ func saveMain(_ *cobra.Command, args []string) error {
 	return nil
 }
 
-func copySrc(src, dest string) error {
-	// Skip the .git directory for copying, if it exists, since we don't want to save the user's
-	// local Git config along with the source code.
-	opt := copy.Options{
-		Skip: func(_ os.FileInfo, src, dest string) (bool, error) {
-			return strings.HasSuffix(src, ".git"), nil
-		},
-		AddPermission: 0600,
+// hasSourceDir returns true if the source code of lib is in a directory, i.e.
+// unless it's read from a module zip with --proxy_dir.
+func hasSourceDir(lib *licenses.Library) bool {
+	if proxyDir == "" {
+		return true
 	}
-	if err := copy.Copy(src, dest, opt); err != nil {
-		return err
+	m := lib.Module()
+	return m != nil && (m.Main || m.IsLocalReplacement())
+}
+
+// saveFile is a file to be saved, either copied from src or with the given data.
+// If link is set, the file is saved as a symbolic link to link instead, and src
+// is only used to read the content it points to.
+type saveFile struct {
+	src  string
+	data []byte
+	link string
+}
+
+// open returns a reader for the content of the file.
+func (f saveFile) open() (io.ReadCloser, error) {
+	if f.data != nil {
+		return io.NopCloser(bytes.NewReader(f.data)), nil
 	}
-	return nil
+	return os.Open(f.src)
 }
 
-func copyNotices(licensePath, dest string) error {
-	if err := copy.Copy(licensePath, filepath.Join(dest, filepath.Base(licensePath))); err != nil {
-		return err
+// saveFiles maps the path of each file to be saved, relative to the save path
+// and using forward slashes, to its content.
+type saveFiles map[string]saveFile
+
+// addSrc adds all files in the src directory, recursively, to be saved in dest.
+// It returns the paths of the added files.
+func (files saveFiles) addSrc(src, dest string) ([]string, error) {
+	var added []string
+	err := filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Skip the .git directory for copying, if it exists, since we don't want to save the user's
+		// local Git config along with the source code.
+		if strings.HasSuffix(p, ".git") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		added = append(added, files.add(path.Join(dest, filepath.ToSlash(rel)), p))
+		return nil
+	})
+	return added, err
+}
+
+// addNotices adds the license file and the copyright notices at the given paths to
+// be saved in dest. Notices keep their path relative to the directory containing the
+// license file, and notices from parent directories are saved at the root of dest,
+// unless a closer notice with the same name was already saved there.
+// It returns the paths of the added files.
+func (files saveFiles) addNotices(licensePath string, notices []string, dest string) ([]string, error) {
+	added := []string{files.add(path.Join(dest, filepath.Base(licensePath)), licensePath)}
+
+	licenseDir := filepath.Dir(licensePath)
+	for _, notice := range notices {
+		rel, err := filepath.Rel(licenseDir, notice)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(rel, "..") {
+			rel = filepath.Base(notice)
+		}
+		noticeDest := path.Join(dest, filepath.ToSlash(rel))
+		if existing, ok := files[noticeDest]; ok && existing.src != notice {
+			klog.Warningf("Not saving notice %s, because %s is already saved as %s", notice, existing.src, noticeDest)
+			continue
+		}
+		added = append(added, files.add(noticeDest, notice))
 	}
+	return added, nil
+}
 
-	src := filepath.Dir(licensePath)
-	files, err := os.ReadDir(src)
+// addPackageSrc adds the license file and copyright notices of lib, and the source
+// files of its packages, to be saved in dest. Source files keep their path relative
+// to the directory containing the license file. It returns the paths of the added files.
+func (files saveFiles) addPackageSrc(lib *licenses.Library, notices []string, dest string) ([]string, error) {
+	added, err := files.addNotices(lib.LicenseFile, notices, dest)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	for _, f := range files {
-		if fName := f.Name(); !f.IsDir() && noticeRegexp.MatchString(fName) {
-			if err := copy.Copy(filepath.Join(src, fName), filepath.Join(dest, fName)); err != nil {
-				return err
-			}
+	libDir := filepath.Dir(lib.LicenseFile)
+	for _, src := range lib.SourceFiles() {
+		rel, err := filepath.Rel(libDir, src)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(rel, "..") {
+			return nil, fmt.Errorf("source file %s of library %s is not under %s", src, lib.Name(), libDir)
+		}
+		added = append(added, files.add(path.Join(dest, filepath.ToSlash(rel)), src))
+	}
+	return added, nil
+}
+
+// add adds the file at src to be saved as dest, and returns dest.
+func (files saveFiles) add(dest, src string) string {
+	files[dest] = saveFile{src: src}
+	return dest
+}
+
+// sortedPaths returns the destination paths of files in lexical order.
+func (files saveFiles) sortedPaths() []string {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// writeDir copies files into the directory dir.
+func writeDir(dir string, files saveFiles) error {
+	for _, p := range files.sortedPaths() {
+		if err := files[p].writeTo(filepath.Join(dir, filepath.FromSlash(p))); err != nil {
+			return err
 		}
 	}
 	return nil
 }
+
+// writeTo writes the file at dest, creating its parent directories if needed.
+func (f saveFile) writeTo(dest string) error {
+	if f.data == nil && f.link == "" {
+		return copy.Copy(f.src, dest, copy.Options{AddPermission: 0600})
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	if f.link != "" {
+		return os.Symlink(filepath.FromSlash(f.link), dest)
+	}
+	return os.WriteFile(dest, f.data, 0644)
+}
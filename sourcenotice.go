@@ -0,0 +1,87 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/google/go-licenses/v2/licenses"
+)
+
+const (
+	// sourceZipName is the name of the module zip in a source bundle.
+	sourceZipName = "source.zip"
+	// sourceNoticeName is the name of the notice describing a source bundle.
+	sourceNoticeName = "SOURCE_NOTICE.txt"
+)
+
+var sourceNoticeTemplate = template.Must(template.New("").Parse(`This distribution includes the Go module {{.Module}} version {{.Version}},
+which is licensed under {{.Licenses}}.
+
+The source code of this module, as used to build this distribution, is included
+in {{.Zip}}. It is the module zip published for this version, as verified
+against the go.sum hash {{.Hash}}.
+{{- if .ProxyURL}}
+
+The same source code is also published by the Go module proxy at:
+{{.ProxyURL}}
+{{- end}}
+`))
+
+// addModuleSource adds a corresponding-source bundle for lib's module to be saved:
+// the module zip from the module cache, verified against sum, and a notice
+// telling where the source code is.
+// Bundles are saved once per module, in a directory named after the module path.
+// It returns the paths of the added files.
+func (files saveFiles) addModuleSource(ctx context.Context, lib *licenses.Library, sum licenses.GoSum) ([]string, error) {
+	zipPath, hash, err := lib.ModuleZip(ctx, sum)
+	if err != nil {
+		return nil, err
+	}
+	dest := unvendor(lib.ModulePath())
+	zipDest := path.Join(dest, sourceZipName)
+	noticeDest := path.Join(dest, sourceNoticeName)
+	if _, ok := files[zipDest]; ok {
+		// Already added for another library of the same module.
+		return []string{zipDest, noticeDest}, nil
+	}
+
+	licenseNames := make([]string, 0, len(lib.Licenses))
+	for _, license := range lib.Licenses {
+		licenseNames = append(licenseNames, license.Name)
+	}
+	proxyURL, _ := lib.TemplateFileURL(licenses.ProxyZipURLTemplate, "")
+	var notice bytes.Buffer
+	if err := sourceNoticeTemplate.Execute(&notice, struct {
+		Module, Version, Licenses, Zip, Hash, ProxyURL string
+	}{
+		Module:   lib.ModulePath(),
+		Version:  lib.Version(),
+		Licenses: strings.Join(licenseNames, ", "),
+		Zip:      sourceZipName,
+		Hash:     hash,
+		ProxyURL: proxyURL,
+	}); err != nil {
+		return nil, err
+	}
+
+	files.add(zipDest, zipPath)
+	files[noticeDest] = saveFile{data: notice.Bytes()}
+	return []string{zipDest, noticeDest}, nil
+}
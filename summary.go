@@ -0,0 +1,140 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/google/go-licenses/v2/licenses"
+	"github.com/spf13/cobra"
+)
+
+// summaryFormat is the format of the summary printed instead of, or after, the
+// output of the command: text or json. It's empty when no summary is printed.
+var summaryFormat string
+
+// addSummaryFlag adds the --summary flag to cmd.
+func addSummaryFlag(cmd *cobra.Command, usage string) {
+	cmd.Flags().StringVar(&summaryFormat, "summary", "", usage+" Either text, the default when the flag has no value, or json.")
+	cmd.Flags().Lookup("summary").NoOptDefVal = "text"
+}
+
+// checkSummaryFormat returns an error if --summary has an invalid value.
+func checkSummaryFormat() error {
+	switch summaryFormat {
+	case "", "text", "json":
+		return nil
+	}
+	return fmt.Errorf("invalid --summary %q: want text or json", summaryFormat)
+}
+
+// licenseSummary counts libraries by license.
+type licenseSummary struct {
+	Libraries int `json:"libraries"`
+	Modules   int `json:"modules"`
+	// Unknown is the number of libraries without an identified license.
+	Unknown         int                                     `json:"unknown"`
+	LicenseNames    map[string]int                          `json:"license_names"`
+	LicenseTypes    map[string]int                          `json:"license_types"`
+	Restrictiveness map[licenses.LicenseRestrictiveness]int `json:"restrictiveness"`
+}
+
+// summarize counts libs by license name, license type and restrictiveness. A
+// library with several licenses counts once for each of their names and types.
+func summarize(libs []*licenses.Library) licenseSummary {
+	s := licenseSummary{
+		Libraries:       len(libs),
+		LicenseNames:    map[string]int{},
+		LicenseTypes:    map[string]int{},
+		Restrictiveness: map[licenses.LicenseRestrictiveness]int{},
+	}
+	modules := map[string]bool{}
+	for _, lib := range libs {
+		if path := lib.ModulePath(); path != "" {
+			modules[path] = true
+		}
+		if len(lib.Licenses) == 0 {
+			s.Unknown++
+		}
+		names := map[string]bool{}
+		types := map[string]bool{}
+		var licenseTypes []licenses.Type
+		for _, license := range lib.Licenses {
+			names[license.Name] = true
+			types[license.Type.String()] = true
+			licenseTypes = append(licenseTypes, license.Type)
+		}
+		for name := range names {
+			s.LicenseNames[name]++
+		}
+		for t := range types {
+			s.LicenseTypes[t]++
+		}
+		s.Restrictiveness[licenses.LicenseTypeRestrictiveness(licenseTypes...)]++
+	}
+	s.Modules = len(modules)
+	return s
+}
+
+// write writes the summary in format, text or json.
+func (s licenseSummary) write(w io.Writer, format string) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(s)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Libraries:\t%d\n", s.Libraries)
+	fmt.Fprintf(tw, "Modules:\t%d\n", s.Modules)
+	fmt.Fprintf(tw, "Unknown licenses:\t%d\n", s.Unknown)
+	restrictiveness := map[string]int{}
+	for r, n := range s.Restrictiveness {
+		restrictiveness[string(r)] = n
+	}
+	for _, section := range []struct {
+		title  string
+		counts map[string]int
+	}{
+		{"License names", s.LicenseNames},
+		{"License types", s.LicenseTypes},
+		{"Restrictiveness", restrictiveness},
+	} {
+		fmt.Fprintf(tw, "\n%s:\n", section.title)
+		for _, key := range sortedByCount(section.counts) {
+			fmt.Fprintf(tw, "  %s\t%d\n", key, section.counts[key])
+		}
+	}
+	return tw.Flush()
+}
+
+// sortedByCount returns the keys of counts, by decreasing count then by key.
+func sortedByCount(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
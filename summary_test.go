@@ -0,0 +1,103 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-licenses/v2/licenses"
+)
+
+func TestSummary(t *testing.T) {
+	libs := []*licenses.Library{
+		{Licenses: []licenses.License{{Name: "MIT", Type: licenses.Notice}}},
+		{Licenses: []licenses.License{{Name: "MIT", Type: licenses.Notice}, {Name: "Apache-2.0", Type: licenses.Notice}}},
+		{Licenses: []licenses.License{{Name: "GPL-2.0", Type: licenses.Restricted}, {Name: "MIT", Type: licenses.Notice}}},
+		{},
+	}
+	got := summarize(libs)
+	want := licenseSummary{
+		Libraries:       4,
+		Unknown:         1,
+		LicenseNames:    map[string]int{"MIT": 3, "Apache-2.0": 1, "GPL-2.0": 1},
+		LicenseTypes:    map[string]int{"notice": 3, "restricted": 1},
+		Restrictiveness: map[licenses.LicenseRestrictiveness]int{licenses.RestrictionsShareLicense: 2, licenses.RestrictionsShareCode: 1, licenses.RestrictionsUnknown: 1},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("summarize() diff (-want +got):\n%s", diff)
+	}
+
+	for _, test := range []struct {
+		format string
+		want   string
+	}{
+		{
+			format: "text",
+			want: `Libraries:         4
+Modules:           0
+Unknown licenses:  1
+
+License names:
+  MIT         3
+  Apache-2.0  1
+  GPL-2.0     1
+
+License types:
+  notice      3
+  restricted  1
+
+Restrictiveness:
+  ShareLicense  2
+  ShareCode     1
+  Unknown       1
+`,
+		},
+		{
+			format: "json",
+			want: `{
+  "libraries": 4,
+  "modules": 0,
+  "unknown": 1,
+  "license_names": {
+    "Apache-2.0": 1,
+    "GPL-2.0": 1,
+    "MIT": 3
+  },
+  "license_types": {
+    "notice": 3,
+    "restricted": 1
+  },
+  "restrictiveness": {
+    "ShareCode": 1,
+    "ShareLicense": 2,
+    "Unknown": 1
+  }
+}
+`,
+		},
+	} {
+		t.Run(test.format, func(t *testing.T) {
+			var out strings.Builder
+			if err := got.write(&out, test.format); err != nil {
+				t.Fatalf("write() = %v", err)
+			}
+			if diff := cmp.Diff(test.want, out.String()); diff != "" {
+				t.Errorf("write() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// syncSummary lists the paths changed by syncDir, relative to the synced directory.
+type syncSummary struct {
+	Added     []string
+	Updated   []string
+	Removed   []string
+	Unchanged int
+}
+
+func (s syncSummary) String() string {
+	return fmt.Sprintf("%d added, %d updated, %d removed, %d unchanged", len(s.Added), len(s.Updated), len(s.Removed), s.Unchanged)
+}
+
+// syncDir updates the directory dir so that it contains exactly files, among
+// the files listed in the manifest of a previous save. Only new and changed files
+// are written, and files of the previous manifest that are not in files anymore
+// are removed, along with directories left empty. Other files are left untouched.
+// A summary of the changes is printed.
+func syncDir(dir string, files saveFiles) error {
+	summary, err := syncFiles(dir, files)
+	if err != nil {
+		return err
+	}
+	for _, p := range summary.Added {
+		fmt.Printf("added %s\n", p)
+	}
+	for _, p := range summary.Updated {
+		fmt.Printf("updated %s\n", p)
+	}
+	for _, p := range summary.Removed {
+		fmt.Printf("removed %s\n", p)
+	}
+	fmt.Printf("Synced %s: %s\n", dir, summary)
+	return nil
+}
+
+func syncFiles(dir string, files saveFiles) (syncSummary, error) {
+	var summary syncSummary
+
+	previous, err := previousSavePaths(dir)
+	if err != nil {
+		return summary, err
+	}
+
+	// Remove stale files first, so that a file can be replaced by a directory of the same name.
+	emptyDirCandidates := map[string]bool{}
+	for _, p := range previous {
+		if _, ok := files[p]; ok {
+			continue
+		}
+		stale := filepath.Join(dir, filepath.FromSlash(p))
+		if err := os.Remove(stale); os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return summary, err
+		}
+		summary.Removed = append(summary.Removed, p)
+		for d := filepath.Dir(stale); d != filepath.Clean(dir); d = filepath.Dir(d) {
+			emptyDirCandidates[d] = true
+		}
+	}
+
+	for _, p := range files.sortedPaths() {
+		dest := filepath.Join(dir, filepath.FromSlash(p))
+		f := files[p]
+		existing, err := os.Lstat(dest)
+		switch {
+		case os.IsNotExist(err):
+			summary.Added = append(summary.Added, p)
+		case err != nil:
+			return summary, err
+		case existing.IsDir():
+			// A directory can't be replaced by a file in place. The files of the
+			// previous manifest were removed above, so any file left in it wasn't
+			// written by save.
+			if err := checkNoFiles(dest); err != nil {
+				return summary, err
+			}
+			if err := os.RemoveAll(dest); err != nil {
+				return summary, err
+			}
+			for d := range emptyDirCandidates {
+				if d == dest || strings.HasPrefix(d, dest+string(filepath.Separator)) {
+					delete(emptyDirCandidates, d)
+				}
+			}
+			summary.Updated = append(summary.Updated, p)
+		default:
+			same, err := sameFile(f, dest, existing)
+			if err != nil {
+				return summary, err
+			}
+			if same {
+				summary.Unchanged++
+				continue
+			}
+			if err := os.Remove(dest); err != nil {
+				return summary, err
+			}
+			summary.Updated = append(summary.Updated, p)
+		}
+		if err := f.writeTo(dest); err != nil {
+			return summary, err
+		}
+	}
+
+	// Remove directories left empty, deepest first.
+	dirs := make([]string, 0, len(emptyDirCandidates))
+	for d := range emptyDirCandidates {
+		dirs = append(dirs, d)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	for _, d := range dirs {
+		entries, err := os.ReadDir(d)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return summary, err
+		}
+		if len(entries) == 0 {
+			if err := os.Remove(d); err != nil {
+				return summary, err
+			}
+		}
+	}
+	return summary, nil
+}
+
+// checkNoFiles returns an error if the directory dir contains files, at any depth.
+func checkNoFiles(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return fmt.Errorf("cannot replace directory %s with a file: %s is not listed in %s", dir, path, manifestPath)
+		}
+		return nil
+	})
+}
+
+// previousSavePaths returns the paths of the files listed in the manifest of the
+// previous save into dir, including the manifest itself, relative to dir. It
+// returns an error if dir isn't empty but has no manifest, so that syncing never
+// removes files that save didn't write.
+func previousSavePaths(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) || err == nil && len(entries) == 0 {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, manifestPath))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s is not empty and has no %s, refusing to sync a directory that wasn't written by save", dir, manifestPath)
+	} else if err != nil {
+		return nil, err
+	}
+	var manifest saveManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filepath.Join(dir, manifestPath), err)
+	}
+	paths := []string{manifestPath}
+	for _, lib := range manifest.Libraries {
+		for _, f := range lib.Files {
+			if !filepath.IsLocal(filepath.FromSlash(f.Path)) {
+				return nil, fmt.Errorf("reading %s: file path %q is outside of %s", filepath.Join(dir, manifestPath), f.Path, dir)
+			}
+			paths = append(paths, f.Path)
+		}
+	}
+	sort.Strings(paths)
+	return slices.Compact(paths), nil
+}
+
+// sameFile reports whether the existing file at path, with the given info, is the same as f.
+func sameFile(f saveFile, path string, existing fs.FileInfo) (bool, error) {
+	isLink := existing.Mode()&fs.ModeSymlink != 0
+	if isLink != (f.link != "") {
+		return false, nil
+	}
+	if isLink {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return false, err
+		}
+		return filepath.ToSlash(target) == f.link, nil
+	}
+	return sameContent(f, path)
+}
+
+// sameContent reports whether the file at path has the same content as f.
+func sameContent(f saveFile, path string) (bool, error) {
+	want, err := f.open()
+	if err != nil {
+		return false, err
+	}
+	defer want.Close()
+	wantData, err := io.ReadAll(want)
+	if err != nil {
+		return false, err
+	}
+	gotData, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(wantData, gotData), nil
+}
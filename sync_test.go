@@ -0,0 +1,163 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSyncFiles(t *testing.T) {
+	dir := t.TempDir()
+	previous := saveFiles{
+		"example.com/kept/LICENSE":    {data: []byte("kept")},
+		"example.com/changed/LICENSE": {data: []byte("old")},
+		"example.com/stale/LICENSE":   {data: []byte("stale")},
+		"example.com/stale/NOTICE":    {data: []byte("stale")},
+	}
+	manifest := saveManifest{}
+	manifest.Libraries = []manifestLibrary{{Name: "example.com"}}
+	for _, p := range previous.sortedPaths() {
+		manifest.Libraries[0].Files = append(manifest.Libraries[0].Files, manifestFile{Path: p})
+	}
+	if err := manifest.addTo(previous); err != nil {
+		t.Fatal(err)
+	}
+	// Files not written by save are kept.
+	previous["README.md"] = saveFile{data: []byte("readme")}
+	previous["example.com/stale/README.md"] = saveFile{data: []byte("readme")}
+	if err := writeDir(dir, previous); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := syncFiles(dir, saveFiles{
+		"example.com/kept/LICENSE":    {data: []byte("kept")},
+		"example.com/changed/LICENSE": {data: []byte("new")},
+		"example.com/new/LICENSE":     {data: []byte("new")},
+	})
+	if err != nil {
+		t.Fatalf("syncFiles() = (_, %v), want (_, nil)", err)
+	}
+	want := syncSummary{
+		Added:     []string{"example.com/new/LICENSE"},
+		Updated:   []string{"example.com/changed/LICENSE"},
+		Removed:   []string{"example.com/stale/LICENSE", "example.com/stale/NOTICE", manifestPath},
+		Unchanged: 1,
+	}
+	if diff := cmp.Diff(want, summary); diff != "" {
+		t.Errorf("syncFiles() summary diff (-want +got): %s", diff)
+	}
+
+	for path, wantContent := range map[string]string{
+		"example.com/kept/LICENSE":    "kept",
+		"example.com/changed/LICENSE": "new",
+		"example.com/new/LICENSE":     "new",
+		"README.md":                   "readme",
+		"example.com/stale/README.md": "readme",
+	} {
+		got, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != wantContent {
+			t.Errorf("content of %s = %q, want %q", path, got, wantContent)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "example.com", "stale", "LICENSE")); !os.IsNotExist(err) {
+		t.Errorf("stale file still exists: %v", err)
+	}
+}
+
+func TestSyncFilesEmptyDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "licenses")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	emptyDir := filepath.Join(dir, "empty")
+	if err := os.Mkdir(emptyDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A directory with only an empty directory isn't empty, and has no manifest.
+	if _, err := syncFiles(dir, saveFiles{"example.com/LICENSE": {data: []byte("new")}}); err == nil {
+		t.Errorf("syncFiles() into a directory without manifest = (_, nil), want an error")
+	}
+
+	if err := os.Remove(emptyDir); err != nil {
+		t.Fatal(err)
+	}
+	summary, err := syncFiles(dir, saveFiles{"example.com/LICENSE": {data: []byte("new")}})
+	if err != nil {
+		t.Fatalf("syncFiles() into an empty directory = (_, %v), want (_, nil)", err)
+	}
+	if diff := cmp.Diff([]string{"example.com/LICENSE"}, summary.Added); diff != "" {
+		t.Errorf("syncFiles() added diff (-want +got): %s", diff)
+	}
+}
+
+func TestSyncFilesDirReplacedByFile(t *testing.T) {
+	for _, test := range []struct {
+		desc    string
+		extra   saveFiles // Files not written by save.
+		wantErr bool
+	}{
+		{
+			desc: "Only files of the previous manifest",
+		},
+		{
+			desc:    "File not written by save",
+			extra:   saveFiles{"example.com/foo/sub/README.md": {data: []byte("readme")}},
+			wantErr: true,
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			dir := t.TempDir()
+			previous := saveFiles{"example.com/foo/sub/LICENSE": {data: []byte("old")}}
+			manifest := saveManifest{}
+			manifest.Libraries = []manifestLibrary{{Name: "example.com/foo", Files: []manifestFile{{Path: "example.com/foo/sub/LICENSE"}}}}
+			if err := manifest.addTo(previous); err != nil {
+				t.Fatal(err)
+			}
+			for p, f := range test.extra {
+				previous[p] = f
+			}
+			if err := writeDir(dir, previous); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := syncFiles(dir, saveFiles{"example.com/foo": {data: []byte("new")}})
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("syncFiles() = (_, %v), want err? %t", err, test.wantErr)
+			}
+			for p := range test.extra {
+				if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(p))); err != nil {
+					t.Errorf("file not written by save was removed: %v", err)
+				}
+			}
+			if test.wantErr {
+				return
+			}
+			got, err := os.ReadFile(filepath.Join(dir, "example.com", "foo"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != "new" {
+				t.Errorf("content of example.com/foo = %q, want %q", got, "new")
+			}
+		})
+	}
+}
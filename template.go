@@ -0,0 +1,208 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"reflect"
+	"regexp"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/go-licenses/v2/licenses"
+)
+
+// templateGroup is a group of items returned by the groupBy template function.
+type templateGroup struct {
+	Key   string
+	Items []any
+}
+
+// copyrightRegexp matches lines that are copyright statements, like "Copyright
+// (c) 2020 Foo", but not license text mentioning copyright or placeholders like
+// "Copyright [yyyy] [name of copyright owner]".
+var copyrightRegexp = regexp.MustCompile(`(?i)^\s*(copyright\s*(\(c\)|©)|(copyright|\(c\)|©)\s*\d{4})`)
+
+// markdownEscaper escapes the characters that have a meaning in Markdown inline
+// text and tables.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`,
+	`<`, `\<`, `>`, `\>`, `|`, `\|`,
+)
+
+// libraryHolder is implemented by the data passed to report templates.
+type libraryHolder interface {
+	library() *licenses.Library
+}
+
+func (lib libraryData) library() *licenses.Library     { return lib.lib }
+func (lib libraryDataFlat) library() *licenses.Library { return lib.lib }
+
+// templateFuncs returns the functions available in report templates. The
+// un-flattened data of the libraries is returned by the "libraries" function.
+func templateFuncs(libs []libraryData) template.FuncMap {
+	return template.FuncMap{
+		"libraries":      func() []libraryData { return libs },
+		"groupBy":        groupBy,
+		"uniq":           uniq,
+		"join":           join,
+		"markdownEscape": markdownEscaper.Replace,
+		"htmlEscape":     html.EscapeString,
+		"noticeText":     noticeText,
+		"copyrights":     copyrights,
+		"date":           date,
+		"toolVersion":    toolVersion,
+	}
+}
+
+// groupBy groups the items of list by the value of their field, in the order of
+// the field values. Items whose field is a slice, like LicenseNames, are in the
+// group of each value in the slice.
+func groupBy(field string, list any) ([]templateGroup, error) {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("groupBy: want a list, got %T", list)
+	}
+	groups := map[string]*templateGroup{}
+	for i := 0; i < v.Len(); i++ {
+		item := reflect.Indirect(v.Index(i))
+		if item.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("groupBy: want a list of structs, got %s", item.Type())
+		}
+		f := item.FieldByName(field)
+		if !f.IsValid() {
+			return nil, fmt.Errorf("groupBy: %s has no field %q", item.Type(), field)
+		}
+		var keys []string
+		if f.Kind() == reflect.Slice {
+			for j := 0; j < f.Len(); j++ {
+				keys = append(keys, fmt.Sprint(f.Index(j).Interface()))
+			}
+		} else {
+			keys = []string{fmt.Sprint(f.Interface())}
+		}
+		for _, key := range keys {
+			if groups[key] == nil {
+				groups[key] = &templateGroup{Key: key}
+			}
+			groups[key].Items = append(groups[key].Items, v.Index(i).Interface())
+		}
+	}
+	result := make([]templateGroup, 0, len(groups))
+	for _, g := range groups {
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+	return result, nil
+}
+
+// uniq returns the items of list without the duplicates, in the order of their
+// first occurrence.
+func uniq(list any) ([]any, error) {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("uniq: want a list, got %T", list)
+	}
+	var result []any
+	seen := map[any]bool{}
+	for i := 0; i < v.Len(); i++ {
+		item := v.Index(i).Interface()
+		if !v.Index(i).Comparable() {
+			return nil, fmt.Errorf("uniq: %T items can't be compared", item)
+		}
+		if !seen[item] {
+			seen[item] = true
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+// join joins the items of list with sep. It takes the separator first, so that a
+// list can be piped to it.
+func join(sep string, list any) (string, error) {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice {
+		return "", fmt.Errorf("join: want a list, got %T", list)
+	}
+	items := make([]string, v.Len())
+	for i := range items {
+		items[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return strings.Join(items, sep), nil
+}
+
+// noticeText returns the content of the copyright notice files of lib, as found
+// by the save command with the default --notice_names, separated by blank lines.
+func noticeText(lib libraryHolder) (string, error) {
+	l := lib.library()
+	if l == nil {
+		return "", nil
+	}
+	notices, err := l.Notices(licenses.NoticeRegexp(licenses.DefaultNoticeNames...))
+	if err != nil {
+		return "", err
+	}
+	var texts []string
+	for _, notice := range notices {
+		data, err := os.ReadFile(notice)
+		if err != nil {
+			return "", err
+		}
+		texts = append(texts, strings.TrimSpace(string(data)))
+	}
+	return strings.Join(texts, "\n\n"), nil
+}
+
+// copyrights returns the copyright statements in text, e.g. the result of
+// LicenseText or noticeText, without duplicates.
+func copyrights(text string) []string {
+	var lines []string
+	seen := map[string]bool{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if copyrightRegexp.MatchString(line) && !seen[line] {
+			seen[line] = true
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// date returns the current date, or the one in the SOURCE_DATE_EPOCH
+// environment variable for reproducible reports, formatted with layout.
+func date(layout string) string {
+	now := time.Now()
+	if epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		now = time.Unix(epoch, 0)
+	}
+	return now.UTC().Format(layout)
+}
+
+// toolVersion returns the version of go-licenses, "(devel)" when built from source.
+func toolVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
@@ -0,0 +1,108 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	libs := []libraryData{
+		{Name: "example.com/a", Version: "v1.0.0", LicenseNames: []string{"MIT"}},
+		{Name: "example.com/b_c", Version: "v2.0.0", LicenseNames: []string{"Apache-2.0", "MIT"}},
+	}
+	flat := []libraryDataFlat{
+		{Name: "example.com/a", LicenseName: "MIT"},
+		{Name: "example.com/b_c", LicenseName: "Apache-2.0"},
+		{Name: "example.com/b_c", LicenseName: "MIT"},
+	}
+	t.Setenv("SOURCE_DATE_EPOCH", "1666000000")
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{
+			name:     "groupBy flat",
+			template: `{{ range groupBy "LicenseName" . }}{{ .Key }}:{{ range .Items }} {{ .Name }}{{ end }};{{ end }}`,
+			want:     "Apache-2.0: example.com/b_c;MIT: example.com/a example.com/b_c;",
+		},
+		{
+			name:     "groupBy slice field",
+			template: `{{ range groupBy "LicenseNames" libraries }}{{ .Key }}:{{ range .Items }} {{ .Version }}{{ end }};{{ end }}`,
+			want:     "Apache-2.0: v2.0.0;MIT: v1.0.0 v2.0.0;",
+		},
+		{
+			name:     "uniq and join",
+			template: `{{ $names := list }}{{ range . }}{{ $names = append $names .Name }}{{ end }}{{ uniq $names | join ", " }}`,
+			want:     "example.com/a, example.com/b_c",
+		},
+		{
+			name:     "join license names",
+			template: `{{ range libraries }}{{ .LicenseNames | join " OR " }};{{ end }}`,
+			want:     "MIT;Apache-2.0 OR MIT;",
+		},
+		{
+			name:     "escape",
+			template: `{{ range libraries }}{{ markdownEscape .Name }} {{ htmlEscape "<a & b>" }};{{ end }}`,
+			want:     `example.com/a &lt;a &amp; b&gt;;example.com/b\_c &lt;a &amp; b&gt;;`,
+		},
+		{
+			name:     "copyrights",
+			template: `{{ copyrights "MIT License\n\nCopyright (c) 2020 Foo\n  Copyright 2021 Bar\n© 2022 Baz\ncopyright notice that is included\nCopyright [yyyy] [name of copyright owner]\nCopyright (c) 2020 Foo\n" | join "|" }}`,
+			want:     "Copyright (c) 2020 Foo|Copyright 2021 Bar|© 2022 Baz",
+		},
+		{
+			name:     "date",
+			template: `{{ date "2006-01-02" }}`,
+			want:     "2022-10-17",
+		},
+		{
+			name:     "noticeText without library",
+			template: `{{ range . }}[{{ noticeText . }}]{{ end }}`,
+			want:     "[][][]",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			funcs := templateFuncs(libs)
+			// Helpers to build lists in the test templates only.
+			funcs["list"] = func() []string { return nil }
+			funcs["append"] = func(list []string, s string) []string { return append(list, s) }
+			tmpl, err := template.New("").Funcs(funcs).Parse(test.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got strings.Builder
+			if err := tmpl.Execute(&got, flat); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got.String()); diff != "" {
+				t.Errorf("template output diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestToolVersion(t *testing.T) {
+	if got := toolVersion(); got == "" {
+		t.Errorf("toolVersion() = %q, want non-empty", got)
+	}
+}